@@ -9,20 +9,50 @@ import (
 	"flag"
 	"log"
 	"math/rand"
+	"os"
 	"runtime"
 	"time"
 
+	"github.com/btmura/blockcillin/internal/asset"
 	"github.com/btmura/blockcillin/internal/audio"
 	"github.com/btmura/blockcillin/internal/game"
+	"github.com/btmura/blockcillin/internal/locale"
 	"github.com/btmura/blockcillin/internal/renderer"
 	"github.com/go-gl/glfw/v3.1/glfw"
 )
 
 var (
-	fullScreen = flag.Bool("fs", true, "use fullscreen")
-	seed       = flag.Int64("s", 0, "seed for the random number generator")
+	fullScreen   = flag.Bool("fs", true, "use fullscreen")
+	seed         = flag.Int64("s", 0, "seed for the random number generator")
+	audioBuffer  = flag.Int("ab", 256, "audio frames per buffer or 0 to let the device choose")
+	audioLatency = flag.Duration("al", 0, "suggested audio output latency or 0 for the device default")
+	synthSounds  = flag.Bool("synth", false, "synthesize the sound effects instead of playing the recorded ones")
+	captureFile  = flag.String("capture", "", "WAV file to capture the audio to or empty to start capturing with F12")
+	lang         = flag.String("lang", os.Getenv("LANG"), "language of the game's text like en, es, or ru")
+	themeDir     = flag.String("themes", "themes", "directory with a subdirectory or zip archive for each theme")
+	theme        = flag.String("theme", asset.DefaultTheme, "ID of the theme to start with, which is its directory or archive name")
+	devDir       = flag.String("dev", "", "directory like internal/asset/data to read assets from and reload them as they change")
 )
 
+// gameKeys maps GLFW keys to the game's keys.
+var gameKeys = map[glfw.Key]game.Key{
+	glfw.KeyLeft:    game.KeyLeft,
+	glfw.KeyRight:   game.KeyRight,
+	glfw.KeyDown:    game.KeyDown,
+	glfw.KeyUp:      game.KeyUp,
+	glfw.KeySpace:   game.KeySpace,
+	glfw.KeyEnter:   game.KeyEnter,
+	glfw.KeyEscape:  game.KeyEscape,
+	glfw.KeyLeftAlt: game.KeyRaise,
+}
+
+// gameKeyActions maps GLFW key actions to the game's key actions.
+var gameKeyActions = map[glfw.Action]game.KeyAction{
+	glfw.Press:   game.KeyPress,
+	glfw.Repeat:  game.KeyRepeat,
+	glfw.Release: game.KeyRelease,
+}
+
 func init() {
 	// This is needed to arrange that main() runs on the main thread.
 	// See documentation for functions that are only allowed to be called from the main thread.
@@ -57,9 +87,33 @@ func main() {
 	logFatalIfErr("glfw.CreateWindow", err)
 	win.MakeContextCurrent()
 
-	logFatalIfErr("audio.Init", audio.Init())
+	if *devDir != "" {
+		logFatalIfErr("asset.InitDev", asset.InitDev(*devDir))
+	}
+
+	// Load the built-in catalogs first, since validating the themes checks every language's fonts.
+	logFatalIfErr("locale.Init", locale.Init(*lang))
+
+	// Load the themes before the sounds and meshes so they are loaded from the starting theme.
+	logFatalIfErr("asset.InitThemes", asset.InitThemes(*themeDir, renderer.ValidateTheme))
+	if !asset.SetTheme(*theme) {
+		log.Printf("theme not found: %s", *theme)
+	}
+	logFatalIfErr("locale.Reload", locale.Reload())
+
+	logFatalIfErr("audio.Init", audio.Init(audio.Config{
+		FramesPerBuffer: *audioBuffer,
+		Latency:         *audioLatency,
+		SynthSounds:     *synthSounds,
+		CaptureFile:     *captureFile,
+	}))
 	defer audio.Terminate()
 
+	// Set the theme first so the other listeners load assets from the new theme.
+	game.Subscribe(handleThemeChange)
+	game.Subscribe(audio.HandleEvent)
+	game.Subscribe(renderer.HandleEvent)
+
 	logFatalIfErr("renderer.Init", renderer.Init())
 	defer renderer.Terminate()
 
@@ -72,7 +126,14 @@ func main() {
 
 	g := game.New()
 	win.SetKeyCallback(func(win *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-		g.KeyCallback(key, action)
+		// Toggle capturing the audio for bug reports and trailers.
+		if key == glfw.KeyF12 && action == glfw.Press {
+			audio.ToggleCapture()
+			return
+		}
+		if k, ok := gameKeys[key]; ok {
+			g.KeyCallback(k, gameKeyActions[action])
+		}
 	})
 
 	var lag float64
@@ -85,6 +146,7 @@ func main() {
 
 		for lag >= game.SecPerUpdate {
 			g.Update()
+			renderer.Update(g)
 			lag -= game.SecPerUpdate
 		}
 		fudge := float32(lag / game.SecPerUpdate)
@@ -100,6 +162,20 @@ func main() {
 	}
 }
 
+// handleThemeChange loads assets from the theme the player picked and reloads the catalogs from it.
+func handleThemeChange(e game.Event) {
+	if e.Type != game.EventThemeChange {
+		return
+	}
+	if !asset.SetTheme(e.Theme) {
+		log.Printf("theme not found: %s", e.Theme)
+		return
+	}
+	if err := locale.Reload(); err != nil {
+		log.Printf("locale.Reload: %v", err)
+	}
+}
+
 func logFatalIfErr(tag string, err error) {
 	if err != nil {
 		log.Fatalf("%s: %v", tag, err)
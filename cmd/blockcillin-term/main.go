@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/btmura/blockcillin/internal/asset"
+	"github.com/btmura/blockcillin/internal/audio"
+	"github.com/btmura/blockcillin/internal/game"
+	"github.com/btmura/blockcillin/internal/locale"
+	"github.com/btmura/blockcillin/internal/term"
+)
+
+var (
+	seed        = flag.Int64("s", 0, "seed for the random number generator")
+	mute        = flag.Bool("mute", false, "play without audio")
+	synthSounds = flag.Bool("synth", false, "synthesize the sound effects instead of playing the recorded ones")
+	logFile     = flag.String("log", "", "file to log to while playing since the game covers the terminal")
+	lang        = flag.String("lang", os.Getenv("LANG"), "language of the game's text like en, es, or ru")
+	themeDir    = flag.String("themes", "themes", "directory with a subdirectory or zip archive for each theme")
+	theme       = flag.String("theme", asset.DefaultTheme, "ID of the theme to start with, which is its directory or archive name")
+)
+
+func main() {
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rand.Seed(*seed)
+
+	// Logging to the terminal would scroll the game, so log to a file or nowhere while playing.
+	var w io.Writer = ioutil.Discard
+	if *logFile != "" {
+		f, err := os.Create(*logFile)
+		logFatalIfErr("os.Create", err)
+		defer f.Close()
+		w = f
+	}
+	log.SetOutput(w)
+	log.Printf("seed: %d", *seed)
+
+	// Only the sounds of themes are played in the terminal, so there are no meshes to validate.
+	logFatalIfErr("asset.InitThemes", asset.InitThemes(*themeDir, nil))
+	if !asset.SetTheme(*theme) {
+		log.Printf("theme not found: %s", *theme)
+	}
+	logFatalIfErr("locale.Init", locale.Init(*lang))
+	game.Subscribe(handleThemeChange)
+
+	if !*mute {
+		logFatalIfErr("audio.Init", audio.Init(audio.Config{
+			SynthSounds: *synthSounds,
+		}))
+		defer audio.Terminate()
+		game.Subscribe(audio.HandleEvent)
+	}
+
+	logFatalIfErr("term.Run", term.Run(game.New(), os.Stdin, os.Stdout))
+}
+
+// handleThemeChange loads assets from the theme the player picked and reloads the catalogs from it.
+func handleThemeChange(e game.Event) {
+	if e.Type != game.EventThemeChange {
+		return
+	}
+	if !asset.SetTheme(e.Theme) {
+		log.Printf("theme not found: %s", e.Theme)
+		return
+	}
+	if err := locale.Reload(); err != nil {
+		log.Printf("locale.Reload: %v", err)
+	}
+}
+
+func logFatalIfErr(tag string, err error) {
+	if err != nil {
+		// Show the error on the restored terminal even if logging to a file.
+		log.SetOutput(os.Stderr)
+		log.Fatalf("%s: %v", tag, err)
+	}
+}
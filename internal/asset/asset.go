@@ -1,22 +1,24 @@
 package asset
 
-import (
-	"bytes"
-	"io"
-)
+import "io"
 
 //go:generate go-bindata -debug -pkg asset -o bindata.go -prefix data data
 
-func Reader(name string) (io.Reader, error) {
-	data, err := Asset(name)
-	if err != nil {
-		return nil, err
-	}
-	return bytes.NewReader(data), nil
+// ReadSeekCloser is a reader of an asset that can seek within it and must be closed.
+type ReadSeekCloser interface {
+	io.Reader
+	io.Seeker
+	io.Closer
+}
+
+// Reader returns a reader of the asset from the current theme. The caller must close it.
+func Reader(name string) (ReadSeekCloser, error) {
+	return current.Reader(name)
 }
 
+// String returns the asset from the current theme as a string.
 func String(name string) (string, error) {
-	data, err := Asset(name)
+	data, err := current.Asset(name)
 	if err != nil {
 		return "", err
 	}
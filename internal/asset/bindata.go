@@ -2,14 +2,33 @@
 // sources:
 // data/CPMono_v07 Bold.ttf
 // data/CPMono_v07 Plain.ttf
+// data/Go-Mono-Bold.ttf
+// data/Go-Mono.ttf
+// data/clear.sfx
 // data/clear.wav
+// data/game.seq
+// data/locale_en.txt
+// data/locale_es.txt
+// data/locale_ru.txt
+// data/menu.wav
+// data/meshes.mtl
 // data/meshes.obj
+// data/move.sfx
 // data/move.wav
+// data/music_arp.sfx
+// data/music_bass.sfx
+// data/music_hat.sfx
+// data/music_kick.sfx
+// data/music_lead.sfx
+// data/music_snare.sfx
+// data/select.sfx
 // data/select.wav
 // data/shader.frag
 // data/shader.vert
+// data/swap.sfx
 // data/swap.wav
 // data/texture.png
+// data/thud.sfx
 // data/thud.wav
 // DO NOT EDIT!
 
@@ -73,6 +92,60 @@ func cpmono_v07PlainTtf() (*asset, error) {
 	return a, err
 }
 
+// goMonoBoldTtf reads file data from disk. It returns an error on failure.
+func goMonoBoldTtf() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/Go-Mono-Bold.ttf"
+	name := "Go-Mono-Bold.ttf"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// goMonoTtf reads file data from disk. It returns an error on failure.
+func goMonoTtf() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/Go-Mono.ttf"
+	name := "Go-Mono.ttf"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// clearSfx reads file data from disk. It returns an error on failure.
+func clearSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/clear.sfx"
+	name := "clear.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
 // clearWav reads file data from disk. It returns an error on failure.
 func clearWav() (*asset, error) {
 	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/clear.wav"
@@ -91,6 +164,114 @@ func clearWav() (*asset, error) {
 	return a, err
 }
 
+// gameSeq reads file data from disk. It returns an error on failure.
+func gameSeq() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/game.seq"
+	name := "game.seq"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// locale_enTxt reads file data from disk. It returns an error on failure.
+func locale_enTxt() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/locale_en.txt"
+	name := "locale_en.txt"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// locale_esTxt reads file data from disk. It returns an error on failure.
+func locale_esTxt() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/locale_es.txt"
+	name := "locale_es.txt"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// locale_ruTxt reads file data from disk. It returns an error on failure.
+func locale_ruTxt() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/locale_ru.txt"
+	name := "locale_ru.txt"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// menuWav reads file data from disk. It returns an error on failure.
+func menuWav() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/menu.wav"
+	name := "menu.wav"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// meshesMtl reads file data from disk. It returns an error on failure.
+func meshesMtl() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/meshes.mtl"
+	name := "meshes.mtl"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
 // meshesObj reads file data from disk. It returns an error on failure.
 func meshesObj() (*asset, error) {
 	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/meshes.obj"
@@ -109,6 +290,24 @@ func meshesObj() (*asset, error) {
 	return a, err
 }
 
+// moveSfx reads file data from disk. It returns an error on failure.
+func moveSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/move.sfx"
+	name := "move.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
 // moveWav reads file data from disk. It returns an error on failure.
 func moveWav() (*asset, error) {
 	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/move.wav"
@@ -127,6 +326,132 @@ func moveWav() (*asset, error) {
 	return a, err
 }
 
+// music_arpSfx reads file data from disk. It returns an error on failure.
+func music_arpSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/music_arp.sfx"
+	name := "music_arp.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// music_bassSfx reads file data from disk. It returns an error on failure.
+func music_bassSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/music_bass.sfx"
+	name := "music_bass.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// music_hatSfx reads file data from disk. It returns an error on failure.
+func music_hatSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/music_hat.sfx"
+	name := "music_hat.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// music_kickSfx reads file data from disk. It returns an error on failure.
+func music_kickSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/music_kick.sfx"
+	name := "music_kick.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// music_leadSfx reads file data from disk. It returns an error on failure.
+func music_leadSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/music_lead.sfx"
+	name := "music_lead.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// music_snareSfx reads file data from disk. It returns an error on failure.
+func music_snareSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/music_snare.sfx"
+	name := "music_snare.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
+// selectSfx reads file data from disk. It returns an error on failure.
+func selectSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/select.sfx"
+	name := "select.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
 // selectWav reads file data from disk. It returns an error on failure.
 func selectWav() (*asset, error) {
 	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/select.wav"
@@ -181,6 +506,24 @@ func shaderVert() (*asset, error) {
 	return a, err
 }
 
+// swapSfx reads file data from disk. It returns an error on failure.
+func swapSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/swap.sfx"
+	name := "swap.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
 // swapWav reads file data from disk. It returns an error on failure.
 func swapWav() (*asset, error) {
 	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/swap.wav"
@@ -217,6 +560,24 @@ func texturePng() (*asset, error) {
 	return a, err
 }
 
+// thudSfx reads file data from disk. It returns an error on failure.
+func thudSfx() (*asset, error) {
+	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/thud.sfx"
+	name := "thud.sfx"
+	bytes, err := bindataRead(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("Error reading asset info %s at %s: %v", name, path, err)
+	}
+
+	a := &asset{bytes: bytes, info: fi}
+	return a, err
+}
+
 // thudWav reads file data from disk. It returns an error on failure.
 func thudWav() (*asset, error) {
 	path := "/home/btmura/work/go/src/github.com/btmura/blockcillin/internal/asset/data/thud.wav"
@@ -289,14 +650,33 @@ func AssetNames() []string {
 var _bindata = map[string]func() (*asset, error){
 	"CPMono_v07 Bold.ttf": cpmono_v07BoldTtf,
 	"CPMono_v07 Plain.ttf": cpmono_v07PlainTtf,
+	"Go-Mono-Bold.ttf": goMonoBoldTtf,
+	"Go-Mono.ttf": goMonoTtf,
+	"clear.sfx": clearSfx,
 	"clear.wav": clearWav,
+	"game.seq": gameSeq,
+	"locale_en.txt": locale_enTxt,
+	"locale_es.txt": locale_esTxt,
+	"locale_ru.txt": locale_ruTxt,
+	"menu.wav": menuWav,
+	"meshes.mtl": meshesMtl,
 	"meshes.obj": meshesObj,
+	"move.sfx": moveSfx,
 	"move.wav": moveWav,
+	"music_arp.sfx": music_arpSfx,
+	"music_bass.sfx": music_bassSfx,
+	"music_hat.sfx": music_hatSfx,
+	"music_kick.sfx": music_kickSfx,
+	"music_lead.sfx": music_leadSfx,
+	"music_snare.sfx": music_snareSfx,
+	"select.sfx": selectSfx,
 	"select.wav": selectWav,
 	"shader.frag": shaderFrag,
 	"shader.vert": shaderVert,
+	"swap.sfx": swapSfx,
 	"swap.wav": swapWav,
 	"texture.png": texturePng,
+	"thud.sfx": thudSfx,
 	"thud.wav": thudWav,
 }
 
@@ -342,14 +722,33 @@ type bintree struct {
 var _bintree = &bintree{nil, map[string]*bintree{
 	"CPMono_v07 Bold.ttf": &bintree{cpmono_v07BoldTtf, map[string]*bintree{}},
 	"CPMono_v07 Plain.ttf": &bintree{cpmono_v07PlainTtf, map[string]*bintree{}},
+	"Go-Mono-Bold.ttf": &bintree{goMonoBoldTtf, map[string]*bintree{}},
+	"Go-Mono.ttf": &bintree{goMonoTtf, map[string]*bintree{}},
+	"clear.sfx": &bintree{clearSfx, map[string]*bintree{}},
 	"clear.wav": &bintree{clearWav, map[string]*bintree{}},
+	"game.seq": &bintree{gameSeq, map[string]*bintree{}},
+	"locale_en.txt": &bintree{locale_enTxt, map[string]*bintree{}},
+	"locale_es.txt": &bintree{locale_esTxt, map[string]*bintree{}},
+	"locale_ru.txt": &bintree{locale_ruTxt, map[string]*bintree{}},
+	"menu.wav": &bintree{menuWav, map[string]*bintree{}},
+	"meshes.mtl": &bintree{meshesMtl, map[string]*bintree{}},
 	"meshes.obj": &bintree{meshesObj, map[string]*bintree{}},
+	"move.sfx": &bintree{moveSfx, map[string]*bintree{}},
 	"move.wav": &bintree{moveWav, map[string]*bintree{}},
+	"music_arp.sfx": &bintree{music_arpSfx, map[string]*bintree{}},
+	"music_bass.sfx": &bintree{music_bassSfx, map[string]*bintree{}},
+	"music_hat.sfx": &bintree{music_hatSfx, map[string]*bintree{}},
+	"music_kick.sfx": &bintree{music_kickSfx, map[string]*bintree{}},
+	"music_lead.sfx": &bintree{music_leadSfx, map[string]*bintree{}},
+	"music_snare.sfx": &bintree{music_snareSfx, map[string]*bintree{}},
+	"select.sfx": &bintree{selectSfx, map[string]*bintree{}},
 	"select.wav": &bintree{selectWav, map[string]*bintree{}},
 	"shader.frag": &bintree{shaderFrag, map[string]*bintree{}},
 	"shader.vert": &bintree{shaderVert, map[string]*bintree{}},
+	"swap.sfx": &bintree{swapSfx, map[string]*bintree{}},
 	"swap.wav": &bintree{swapWav, map[string]*bintree{}},
 	"texture.png": &bintree{texturePng, map[string]*bintree{}},
+	"thud.sfx": &bintree{thudSfx, map[string]*bintree{}},
 	"thud.wav": &bintree{thudWav, map[string]*bintree{}},
 }}
 
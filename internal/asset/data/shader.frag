@@ -8,20 +8,28 @@ uniform float u_alpha;
 uniform vec3 u_mixColor;
 uniform float u_mixAmount;
 
+uniform vec3 u_diffuseColor;
+uniform vec3 u_specularColor;
+uniform vec3 u_emissiveColor;
+
 in vec2 texCoord;
 in vec3 lighting;
+in float specular;
 
 out vec4 fragColor;
 
 void main(void) {
-	vec4 color = texture2D(u_texture, texCoord);
+	vec4 color = texture2D(u_texture, texCoord) * vec4(u_diffuseColor, 1.0);
 	color.rgb += u_brightness;
 	color.a *= u_alpha;
 
+	// Light the color before darkening and graying it, so highlights and glows fade out too.
+	color.rgb = color.rgb * lighting + u_specularColor * specular + u_emissiveColor;
+
 	color = vec4(mix(color.rgb, u_mixColor, u_mixAmount), color.a);
 
 	vec3 grayColor = vec3(color.r * 0.21 + color.g * 0.72 + color.b * 0.07);
 	color = vec4(mix(color.rgb, grayColor, u_grayscale), color.a);
 
-	fragColor = vec4(color.rgb * lighting, color.a);
+	fragColor = color;
 }
\ No newline at end of file
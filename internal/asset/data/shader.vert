@@ -3,24 +3,34 @@
 uniform mat4 u_projectionViewMatrix;
 uniform mat4 u_modelMatrix;
 uniform mat4 u_normalMatrix;
+uniform vec4 u_texCoordRect;
 
 uniform vec3 u_ambientLightColor;
 uniform vec3 u_directionalLightColor;
 uniform vec3 u_directionalVector;
 
+uniform float u_shininess;
+
 layout (location = 0) in vec4 i_position;
 layout (location = 1) in vec4 i_normal;
 layout (location = 2) in vec2 i_texCoord;
 
 out vec2 texCoord;
 out vec3 lighting;
+out float specular;
 
 void main(void) {
 	gl_Position = u_projectionViewMatrix * u_modelMatrix * i_position;
 
-	texCoord = i_texCoord;
+	texCoord = u_texCoordRect.xy + i_texCoord * u_texCoordRect.zw;
 
 	vec4 transformedNormal = u_normalMatrix * vec4(i_normal.xyz, 1.0);
 	float directional = max(dot(transformedNormal.xyz, u_directionalVector), 0.0);
 	lighting = u_ambientLightColor + (u_directionalLightColor * directional);
+
+	// Blinn-Phong with the camera looking down the view space's z-axis.
+	// Clamp the exponent, since pow is undefined for a base and exponent of 0.
+	vec3 halfVector = normalize(u_directionalVector + vec3(0.0, 0.0, 1.0));
+	float facing = max(dot(transformedNormal.xyz, halfVector), 0.0);
+	specular = directional > 0.0 ? pow(facing, max(u_shininess, 1.0)) : 0.0;
 }
\ No newline at end of file
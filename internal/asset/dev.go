@@ -0,0 +1,143 @@
+package asset
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// devPollInterval is how often the development directory is checked for changed assets.
+const devPollInterval = 500 * time.Millisecond
+
+var (
+	// devDir is the directory that built-in assets are read from in development mode
+	// or empty to use the assets built into the binary.
+	devDir string
+
+	// changedMu guards changed.
+	changedMu sync.Mutex
+
+	// changed is the set of asset names that changed since Changed was last called.
+	changed = map[string]bool{}
+)
+
+// InitDev starts development mode, which reads the built-in assets from the directory
+// instead of the binary and watches the directory for assets that change while playing.
+// Themes still override the assets read from the directory.
+func InitDev(dir string) error {
+	w := &devWatcher{dir: dir}
+	if _, err := w.poll(); err != nil {
+		return err
+	}
+	devDir = dir
+
+	go func() {
+		for range time.Tick(devPollInterval) {
+			names, err := w.poll()
+			if err != nil {
+				log.Printf("asset: watching %s: %v", dir, err)
+				continue
+			}
+
+			changedMu.Lock()
+			for _, name := range names {
+				changed[name] = true
+			}
+			changedMu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// Changed returns the sorted names of the assets that changed in the development directory
+// since it was last called. It always returns nil outside of development mode.
+func Changed() []string {
+	changedMu.Lock()
+	defer changedMu.Unlock()
+
+	var names []string
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	changed = map[string]bool{}
+	return names
+}
+
+// builtInAsset returns the asset from the development directory in development mode
+// or the asset built into the binary.
+func builtInAsset(name string) ([]byte, error) {
+	if devDir != "" {
+		return ioutil.ReadFile(filepath.Join(devDir, name))
+	}
+	return Asset(name)
+}
+
+// builtInReader returns a reader of the asset's file in the development directory
+// in development mode or of the asset built into the binary.
+func builtInReader(name string) (ReadSeekCloser, error) {
+	if devDir != "" {
+		return os.Open(filepath.Join(devDir, name))
+	}
+	data, err := Asset(name)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+// nopCloser is a reader of data in memory that has nothing to close.
+type nopCloser struct {
+	*bytes.Reader
+}
+
+// Close does nothing.
+func (nopCloser) Close() error {
+	return nil
+}
+
+// devWatcher finds the files in a directory that changed by comparing their modification times.
+type devWatcher struct {
+	// dir is the directory to watch.
+	dir string
+
+	// modTimes maps file name to modification time when the directory was last polled
+	// or nil before it is first polled.
+	modTimes map[string]time.Time
+}
+
+// poll returns the sorted names of the files that were added or modified since
+// the last poll. The first poll only records the files and returns nothing.
+func (w *devWatcher) poll() ([]string, error) {
+	fis, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	modTimes := map[string]time.Time{}
+	var names []string
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		modTimes[fi.Name()] = fi.ModTime()
+		if w.modTimes == nil {
+			continue
+		}
+		if t, ok := w.modTimes[fi.Name()]; !ok || !t.Equal(fi.ModTime()) {
+			names = append(names, fi.Name())
+		}
+	}
+
+	if len(modTimes) == 0 && w.modTimes == nil {
+		return nil, fmt.Errorf("no assets in %s", w.dir)
+	}
+	w.modTimes = modTimes
+	return names, nil
+}
@@ -0,0 +1,80 @@
+package asset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDevWatcherPoll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dev")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := &devWatcher{dir: dir}
+	if _, err := w.poll(); err == nil {
+		t.Errorf("poll() of an empty directory should return an error")
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"shader.vert": "vert",
+		"shader.frag": "frag",
+	})
+
+	// Change the modification times explicitly since the file system may not be precise enough.
+	touch := func(name string, sec int) {
+		mt := time.Unix(int64(sec), 0)
+		if err := os.Chtimes(filepath.Join(dir, name), mt, mt); err != nil {
+			t.Fatalf("os.Chtimes: %v", err)
+		}
+	}
+	touch("shader.vert", 1)
+	touch("shader.frag", 1)
+
+	for _, tt := range []struct {
+		desc   string
+		change func()
+		want   []string
+	}{
+		{
+			desc:   "first poll only records the files",
+			change: func() {},
+		},
+		{
+			desc:   "nothing changed",
+			change: func() {},
+		},
+		{
+			desc: "modified file",
+			change: func() {
+				touch("shader.frag", 2)
+			},
+			want: []string{"shader.frag"},
+		},
+		{
+			desc: "added files",
+			change: func() {
+				writeFiles(t, dir, map[string]string{
+					"meshes.obj":  "obj",
+					"texture.png": "png",
+				})
+			},
+			want: []string{"meshes.obj", "texture.png"},
+		},
+	} {
+		tt.change()
+		got, err := w.poll()
+		if err != nil {
+			t.Errorf("[%s] poll() err = %v, want nil", tt.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] poll() = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package asset
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Entry is a key and value on a line of a file like a theme's manifest or a language's catalog.
+type Entry struct {
+	// Key is the text before the first equals sign.
+	Key string
+
+	// Value is the text after the first equals sign, which may be empty.
+	Value string
+
+	// LineNum is the number of the entry's line starting from 1.
+	LineNum int
+}
+
+// DecodeEntries decodes a file with a "key = value" entry on each line in the order they appear.
+// Blank lines and lines starting with # are skipped. Keys must be unique.
+func DecodeEntries(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	seen := map[string]bool{}
+
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: entry should have a key, =, and value", lineNum)
+		}
+
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNum)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key: %s", lineNum, key)
+		}
+		seen[key] = true
+		entries = append(entries, Entry{key, value, lineNum})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,64 @@
+package asset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeEntries(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    []Entry
+		wantErr bool
+	}{
+		{
+			desc: "entries with comments and blank lines",
+			input: "# Neon theme.\n" +
+				"name = Neon\n" +
+				"\n" +
+				"  texture.png=images/neon.png  \n" +
+				"CPMono_v07 Plain.ttf = fonts/neon.ttf\n",
+			want: []Entry{
+				{"name", "Neon", 2},
+				{"texture.png", "images/neon.png", 4},
+				{"CPMono_v07 Plain.ttf", "fonts/neon.ttf", 5},
+			},
+		},
+		{
+			desc:  "value with an equals sign",
+			input: "key = a = b\n",
+			want:  []Entry{{"key", "a = b", 1}},
+		},
+		{
+			desc:  "empty value",
+			input: "key =\n",
+			want:  []Entry{{"key", "", 1}},
+		},
+		{
+			desc:    "missing equals sign",
+			input:   "texture.png images/neon.png\n",
+			wantErr: true,
+		},
+		{
+			desc:    "missing key",
+			input:   " = images/neon.png\n",
+			wantErr: true,
+		},
+		{
+			desc:    "duplicate key",
+			input:   "texture.png = a.png\ntexture.png = b.png\n",
+			wantErr: true,
+		},
+	} {
+		got, gotErr := DecodeEntries(strings.NewReader(tt.input))
+		if (gotErr != nil) != tt.wantErr {
+			t.Errorf("[%s] DecodeEntries(%q) err = %v, want err %t", tt.desc, tt.input, gotErr, tt.wantErr)
+			continue
+		}
+		if gotErr == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] DecodeEntries(%q) = %v, want %v", tt.desc, tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package asset
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultTheme is the ID of the built-in theme that overrides no assets.
+	DefaultTheme = "default"
+
+	// manifestName is the name of the manifest at the top of a theme's directory or archive.
+	manifestName = "theme.txt"
+
+	// manifestNameKey is the manifest key of the theme's name. Every other key is the name
+	// of an asset to override with the file at the path after the equals sign.
+	manifestNameKey = "name"
+
+	// archiveExt is the extension of themes packed into a zip archive.
+	archiveExt = ".zip"
+)
+
+// Theme is a directory or zip archive of files that override any of the built-in assets.
+type Theme struct {
+	// ID is the theme's ID, which is the name of its directory or archive without the extension.
+	ID string
+
+	// Name is the theme's name from its manifest or empty for the default theme.
+	Name string
+
+	// files maps asset name to the slash-separated path of the file that overrides it.
+	files map[string]string
+
+	// open returns a reader of the file at the slash-separated path in the theme.
+	open func(file string) (ReadSeekCloser, error)
+}
+
+var (
+	// defaultTheme is the built-in theme that overrides no assets.
+	defaultTheme = &Theme{ID: DefaultTheme}
+
+	// themes are the default theme followed by the themes found by InitThemes sorted by ID.
+	themes = []*Theme{defaultTheme}
+
+	// current is the theme that assets are loaded from.
+	current = defaultTheme
+)
+
+// InitThemes loads the themes in the directory, which has a subdirectory or zip archive
+// for each theme. Themes that cannot be loaded or fail the validate function are logged
+// and skipped so that a broken theme does not stop the game. The validate function may be nil.
+// An empty or missing directory has no themes.
+func InitThemes(dir string, validate func(t *Theme) error) error {
+	themes = []*Theme{defaultTheme}
+	current = defaultTheme
+	if dir == "" {
+		return nil
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range fis {
+		p := filepath.Join(dir, fi.Name())
+
+		var t *Theme
+		switch {
+		case fi.IsDir():
+			t, err = loadThemeDir(p)
+		case strings.EqualFold(filepath.Ext(p), archiveExt):
+			t, err = loadThemeArchive(p)
+		default:
+			continue
+		}
+
+		if err == nil && validate != nil {
+			err = validate(t)
+		}
+		if err != nil {
+			log.Printf("skipping theme %s: %v", p, err)
+			continue
+		}
+
+		log.Printf("theme %s: %s: %d assets", t.ID, t.Name, len(t.files))
+		themes = append(themes, t)
+	}
+	return nil
+}
+
+// Themes returns the default theme followed by the other themes sorted by ID.
+func Themes() []*Theme {
+	return themes
+}
+
+// CurrentTheme returns the theme that assets are loaded from.
+func CurrentTheme() *Theme {
+	return current
+}
+
+// SetTheme loads assets from the theme with the ID from now on.
+// It returns false and keeps the current theme if there is no theme with the ID.
+func SetTheme(id string) bool {
+	for _, t := range themes {
+		if t.ID == id {
+			current = t
+			return true
+		}
+	}
+	return false
+}
+
+// Asset returns the asset from the theme's file that overrides it or the built-in asset.
+func (t *Theme) Asset(name string) ([]byte, error) {
+	if f, ok := t.files[name]; ok {
+		b, err := readFile(t.open, f)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %v", t.ID, err)
+		}
+		return b, nil
+	}
+	return builtInAsset(name)
+}
+
+// Reader returns a reader of the asset from the theme's file that overrides it or the built-in asset.
+// It reads the file as it goes instead of all at once, so long assets like music can be streamed.
+// The caller must close it.
+func (t *Theme) Reader(name string) (ReadSeekCloser, error) {
+	if f, ok := t.files[name]; ok {
+		r, err := t.open(f)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %v", t.ID, err)
+		}
+		return r, nil
+	}
+	return builtInReader(name)
+}
+
+// readFile returns the contents of the file opened with the open function.
+func readFile(open func(file string) (ReadSeekCloser, error), file string) ([]byte, error) {
+	r, err := open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// loadThemeDir loads the theme in the directory.
+func loadThemeDir(dir string) (*Theme, error) {
+	open := func(file string) (ReadSeekCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+	}
+	exists := func(file string) bool {
+		fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file)))
+		return err == nil && !fi.IsDir()
+	}
+	return loadTheme(filepath.Base(dir), open, exists)
+}
+
+// loadThemeArchive loads the theme in the zip archive.
+// The archive is opened again to read each file so that it is only held open while a file is read.
+func loadThemeArchive(name string) (*Theme, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]bool{}
+	for _, f := range zr.File {
+		files[f.Name] = true
+	}
+	zr.Close()
+
+	open := func(file string) (ReadSeekCloser, error) {
+		return openArchiveFile(name, file)
+	}
+	exists := func(file string) bool {
+		return files[file]
+	}
+	id := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	return loadTheme(id, open, exists)
+}
+
+// openArchiveFile returns a reader of the file at the slash-separated path in the zip archive.
+// Stored files are read straight from the archive, so the archive stays open until the reader
+// is closed. Compressed files cannot seek, so they are decompressed into memory instead.
+func openArchiveFile(name, file string) (ReadSeekCloser, error) {
+	af, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	keepOpen := false
+	defer func() {
+		if !keepOpen {
+			af.Close()
+		}
+	}()
+
+	fi, err := af.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(af, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range zr.File {
+		if f.Name != file {
+			continue
+		}
+
+		if f.Method == zip.Store {
+			off, err := f.DataOffset()
+			if err != nil {
+				return nil, err
+			}
+			keepOpen = true
+			return &archiveEntry{io.NewSectionReader(af, off, int64(f.UncompressedSize64)), af}, nil
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		data, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		return nopCloser{bytes.NewReader(data)}, nil
+	}
+	return nil, fmt.Errorf("file not found: %s", file)
+}
+
+// archiveEntry is a reader of a stored file in a zip archive that closes the archive.
+type archiveEntry struct {
+	*io.SectionReader
+
+	// archive is the open archive that the file is read from.
+	archive *os.File
+}
+
+// Close closes the archive.
+func (e *archiveEntry) Close() error {
+	return e.archive.Close()
+}
+
+// loadTheme decodes the manifest of the theme with the ID and checks that
+// every asset it overrides is a built-in asset with a file in the theme.
+func loadTheme(id string, open func(file string) (ReadSeekCloser, error), exists func(file string) bool) (*Theme, error) {
+	if id == DefaultTheme {
+		return nil, fmt.Errorf("theme ID is reserved: %s", id)
+	}
+
+	b, err := readFile(open, manifestName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := DecodeEntries(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", manifestName, err)
+	}
+
+	t := &Theme{
+		ID:    id,
+		Name:  id,
+		files: map[string]string{},
+		open:  open,
+	}
+
+	builtIn := map[string]bool{}
+	for _, name := range AssetNames() {
+		builtIn[name] = true
+	}
+
+	for _, e := range entries {
+		if e.Value == "" {
+			return nil, fmt.Errorf("%s: line %d: missing value: %s", manifestName, e.LineNum, e.Key)
+		}
+		if e.Key == manifestNameKey {
+			t.Name = e.Value
+			continue
+		}
+		if !builtIn[e.Key] {
+			return nil, fmt.Errorf("%s: line %d: unknown asset: %s", manifestName, e.LineNum, e.Key)
+		}
+		// Keep files inside the theme, so a theme cannot read any file the player can.
+		f := path.Clean(e.Value)
+		if path.IsAbs(f) || filepath.IsAbs(filepath.FromSlash(f)) || f == ".." || strings.HasPrefix(f, "../") {
+			return nil, fmt.Errorf("%s: line %d: file outside of theme: %s", manifestName, e.LineNum, e.Value)
+		}
+		if !exists(f) {
+			return nil, fmt.Errorf("%s: line %d: file not found: %s", manifestName, e.LineNum, e.Value)
+		}
+		t.files[e.Key] = f
+	}
+	return t, nil
+}
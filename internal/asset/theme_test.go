@@ -0,0 +1,232 @@
+package asset
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInitThemes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themes")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, filepath.Join(dir, "neon"), map[string]string{
+		manifestName:        "name = Neon\nshader.vert = shaders/neon.vert\n",
+		"shaders/neon.vert": "neon",
+	})
+	writeZip(t, filepath.Join(dir, "retro.zip"), zip.Deflate, map[string]string{
+		manifestName: "name = Retro\nshader.frag = retro.frag\n",
+		"retro.frag": "retro",
+	})
+	writeFiles(t, filepath.Join(dir, "broken"), map[string]string{
+		manifestName: "not-an-asset.png = broken.png\n",
+		"broken.png": "broken",
+	})
+	writeFiles(t, filepath.Join(dir, "empty"), map[string]string{
+		manifestName: "name = Empty\ntexture.png =\n",
+	})
+	writeFiles(t, filepath.Join(dir, "missing"), map[string]string{
+		manifestName: "texture.png = missing.png\n",
+	})
+	writeFiles(t, filepath.Join(dir, "traversal"), map[string]string{
+		manifestName: "texture.png = ../neon/shaders/neon.vert\n",
+	})
+	writeFiles(t, filepath.Join(dir, "absolute"), map[string]string{
+		manifestName: "texture.png = " + filepath.ToSlash(filepath.Join(dir, "neon", "shaders", "neon.vert")) + "\n",
+	})
+	writeZip(t, filepath.Join(dir, "traversal_zip.zip"), zip.Deflate, map[string]string{
+		manifestName:    "shader.frag = sub/../../retro.frag\n",
+		"../retro.frag": "retro",
+	})
+	writeFiles(t, filepath.Join(dir, "invalid"), map[string]string{
+		manifestName: "name = Invalid\n",
+	})
+	writeFiles(t, dir, map[string]string{
+		"README.txt": "not a theme",
+	})
+
+	defer InitThemes("", nil)
+	if err := InitThemes(dir, func(t *Theme) error {
+		if t.ID == "invalid" {
+			return errors.New("invalid theme")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("InitThemes: %v", err)
+	}
+
+	var ids, names []string
+	for _, th := range Themes() {
+		ids = append(ids, th.ID)
+		names = append(names, th.Name)
+	}
+	if want := []string{DefaultTheme, "neon", "retro"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Themes() IDs = %v, want %v", ids, want)
+	}
+	if want := []string{"", "Neon", "Retro"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Themes() names = %v, want %v", names, want)
+	}
+
+	builtInVert, err := Asset("shader.vert")
+	if err != nil {
+		t.Fatalf("Asset: %v", err)
+	}
+	builtInFrag, err := Asset("shader.frag")
+	if err != nil {
+		t.Fatalf("Asset: %v", err)
+	}
+
+	for _, tt := range []struct {
+		desc     string
+		theme    string
+		wantVert string
+		wantFrag string
+	}{
+		{
+			desc:     "default theme has the built-in assets",
+			theme:    DefaultTheme,
+			wantVert: string(builtInVert),
+			wantFrag: string(builtInFrag),
+		},
+		{
+			desc:     "theme directory overrides one asset",
+			theme:    "neon",
+			wantVert: "neon",
+			wantFrag: string(builtInFrag),
+		},
+		{
+			desc:     "theme archive overrides one asset",
+			theme:    "retro",
+			wantVert: string(builtInVert),
+			wantFrag: "retro",
+		},
+	} {
+		if !SetTheme(tt.theme) {
+			t.Errorf("[%s] SetTheme(%q) = false, want true", tt.desc, tt.theme)
+			continue
+		}
+		if got, err := String("shader.vert"); err != nil || got != tt.wantVert {
+			t.Errorf("[%s] String(%q) = (%.10q, %v), want (%.10q, nil)", tt.desc, "shader.vert", got, err, tt.wantVert)
+		}
+		if got, err := String("shader.frag"); err != nil || got != tt.wantFrag {
+			t.Errorf("[%s] String(%q) = (%.10q, %v), want (%.10q, nil)", tt.desc, "shader.frag", got, err, tt.wantFrag)
+		}
+	}
+
+	if SetTheme("broken") {
+		t.Errorf("SetTheme(%q) = true, want false", "broken")
+	}
+}
+
+func TestThemeReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themes")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	const content = "0123456789"
+	files := map[string]string{
+		manifestName: "shader.frag = music.wav\n",
+		"music.wav":  content,
+	}
+	writeFiles(t, filepath.Join(dir, "dir"), files)
+	writeZip(t, filepath.Join(dir, "stored.zip"), zip.Store, files)
+	writeZip(t, filepath.Join(dir, "deflated.zip"), zip.Deflate, files)
+
+	defer InitThemes("", nil)
+	if err := InitThemes(dir, nil); err != nil {
+		t.Fatalf("InitThemes: %v", err)
+	}
+
+	for _, tt := range []struct {
+		desc     string
+		theme    string
+		wantType string
+	}{
+		{
+			desc:     "theme directory streams from the file",
+			theme:    "dir",
+			wantType: "*os.File",
+		},
+		{
+			desc:     "stored file streams from the archive",
+			theme:    "stored",
+			wantType: "*asset.archiveEntry",
+		},
+		{
+			desc:     "compressed file is read into memory",
+			theme:    "deflated",
+			wantType: "asset.nopCloser",
+		},
+	} {
+		if !SetTheme(tt.theme) {
+			t.Errorf("[%s] SetTheme(%q) = false, want true", tt.desc, tt.theme)
+			continue
+		}
+
+		r, err := Reader("shader.frag")
+		if err != nil {
+			t.Errorf("[%s] Reader: %v", tt.desc, err)
+			continue
+		}
+
+		if got := fmt.Sprintf("%T", r); got != tt.wantType {
+			t.Errorf("[%s] Reader type = %s, want %s", tt.desc, got, tt.wantType)
+		}
+		if _, err := r.Seek(4, io.SeekStart); err != nil {
+			t.Errorf("[%s] Seek: %v", tt.desc, err)
+		}
+		if got, err := ioutil.ReadAll(r); err != nil || string(got) != content[4:] {
+			t.Errorf("[%s] ReadAll = (%q, %v), want (%q, nil)", tt.desc, got, err, content[4:])
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("[%s] Close: %v", tt.desc, err)
+		}
+	}
+}
+
+// writeFiles writes the files with the contents to the directory.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("os.MkdirAll: %v", err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("ioutil.WriteFile: %v", err)
+		}
+	}
+}
+
+// writeZip writes a zip archive with the files and contents compressed with the method.
+func writeZip(t *testing.T, name string, method uint16, files map[string]string) {
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("os.Create: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatalf("zip.Create: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("zip.Write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip.Close: %v", err)
+	}
+}
@@ -1,16 +1,24 @@
 package audio
 
 import (
-	"io"
+	"fmt"
 	"log"
+	"os"
+	"path"
+	"sync/atomic"
 	"time"
 
 	"github.com/btmura/blockcillin/internal/asset"
-	"github.com/gordonklaus/portaudio"
 )
 
-// sonudQueueSize is how many sounds can be queued at once.
-const soundQueueSize = 100
+const (
+	numOutputChannels = 2     /* stereo output */
+	sampleRate        = 44100 /* samples per second */
+)
+
+// soundRepeatTicks is how many game updates must pass before the same sound can play again.
+// It is one so identical sounds triggered in the same update play only once.
+const soundRepeatTicks = 1
 
 // Sound is an enum that identifies a short sound in the game.
 //go:generate stringer -type=Sound
@@ -33,153 +41,514 @@ var soundAssets = [...]string{
 	SoundThud:   "thud.wav",
 }
 
-// Play plays the given sound. It is overridden by Init.
-var Play = func(s Sound) {}
+// soundSynthAssets maps Sound to the asset name of its synth description.
+var soundSynthAssets = [...]string{
+	SoundMove:   "move.sfx",
+	SoundSelect: "select.sfx",
+	SoundSwap:   "swap.sfx",
+	SoundClear:  "clear.sfx",
+	SoundThud:   "thud.sfx",
+}
+
+// Play plays the given sound in the center for the game update with the tick. It never blocks
+// but may drop the sound if too many are playing or the same sound was played for the same update.
+// It must only be called by the goroutine that calls Init and is safe to call after Terminate.
+// It is overridden by Init.
+var Play = func(s Sound, tick int) {}
+
+// PlayAt plays the given sound from a cell on the board with a pitch ratio where 1 is the
+// original pitch for the game update with the tick. It is overridden by Init.
+var PlayAt = func(s Sound, pos Position, pitch float32, tick int) {}
+
+// ToggleCapture starts capturing the mixed audio to a new WAV file named after the current time
+// or stops and finishes the file if already capturing. It is overridden by Init.
+var ToggleCapture = func() {}
+
+// SetPaused pauses the playing sounds and music in place or resumes them where they left off.
+// Sounds played while paused are not paused. It is overridden by Init.
+var SetPaused = func(paused bool) {}
+
+// FadeOut fades out all the sounds and music before the game exits. It is overridden by Init.
+var FadeOut = func() {}
+
+// ReloadSounds loads the sounds again from the current theme after it changes.
+// Sounds that are already playing finish with their old samples. It is overridden by Init.
+var ReloadSounds = func() {}
 
-// Terminate shuts down the audio system. It is overridden by Init.
+// Terminate shuts down the audio system after fading out the playing sounds. It is overridden by Init.
 var Terminate = func() {}
 
-// Init starts the audio system, loads sound assets, and starts the sound loop.
-func Init() error {
-	if err := portaudio.Initialize(); err != nil {
+// Config configures the audio output.
+type Config struct {
+	// FramesPerBuffer is how many frames are mixed on each callback from the output device.
+	// Smaller buffers lower the latency but need more frequent callbacks.
+	// Zero lets the device choose.
+	FramesPerBuffer int
+
+	// Latency is the suggested output latency of the device. Zero uses the device's default low latency.
+	Latency time.Duration
+
+	// SynthSounds renders the sound effects from their synth descriptions instead of loading the recorded ones.
+	SynthSounds bool
+
+	// CaptureFile is the name of a WAV file to capture the mixed audio to from the start
+	// or empty to not capture until ToggleCapture is called.
+	CaptureFile string
+}
+
+// Init loads sound assets and starts playing audio on the default output device.
+// It falls back to playing silence if there is no usable output device.
+func Init(cfg Config) error {
+	soundBuffers, err := loadSounds(cfg.SynthSounds)
+	if err != nil {
 		return err
 	}
 
-	log.Printf("PortAudio version: %d %s", portaudio.Version(), portaudio.VersionText())
+	p := newPlayer(soundBuffers)
+
+	// c is the current capture. Only the goroutine that calls Init uses it.
+	var c *capture
+
+	// stopped are the captures that were stopped and may still be finishing their files
+	// in the background. Terminate waits for them so that no file is left unfinished.
+	var stopped []*capture
+
+	startCapture := func(name string) error {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		cp, err := newCapture(f, name, cfg.FramesPerBuffer)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if !p.startCapture(cp) {
+			cp.close()
+			cp.wait()
+			return fmt.Errorf("command queue full")
+		}
+		c = cp
+		log.Printf("audio: capturing to %s", name)
+		return nil
+	}
+
+	if cfg.CaptureFile != "" {
+		if err := startCapture(cfg.CaptureFile); err != nil {
+			return err
+		}
+	}
+
+	var b backend = &portAudioBackend{
+		framesPerBuffer: cfg.FramesPerBuffer,
+		latency:         cfg.Latency,
+	}
+	if err := b.start(p.render); err != nil {
+		log.Printf("audio: no output device, playing silence: %v", err)
+		b = &nullBackend{framesPerBuffer: cfg.FramesPerBuffer}
+		if err := b.start(p.render); err != nil {
+			return err
+		}
+	}
+
+	Play = func(s Sound, tick int) {
+		p.play(s, nil, 1, tick)
+	}
+	PlayAt = func(s Sound, pos Position, pitch float32, tick int) {
+		p.play(s, &pos, pitch, tick)
+	}
+
+	SetPaused = func(paused bool) {
+		typ := commandResume
+		if paused {
+			typ = commandPause
+		}
+		if !p.commands.push(command{typ: typ}) {
+			log.Printf("audio: command queue full, dropping pause")
+		}
+	}
+	FadeOut = func() {
+		if !p.commands.push(command{typ: commandFadeOut}) {
+			log.Printf("audio: command queue full, dropping fade out")
+		}
+	}
+
+	currentMusic := MusicNone
+	var stopMusic func()
+	PlayMusic = func(m Music) {
+		// Keep playing the current music instead of restarting it.
+		if m == currentMusic {
+			return
+		}
+		currentMusic = m
+
+		// Stop the old music once it has faded out.
+		if stopMusic != nil {
+			time.AfterFunc(musicStopDelay, stopMusic)
+			stopMusic = nil
+		}
+
+		src, stop, err := openMusic(m)
+		if err != nil {
+			log.Printf("openMusic: %v", err)
+			return
+		}
+		stopMusic = stop
+		p.playMusic(src)
+	}
+
+	ReloadSounds = func() {
+		soundBuffers, err := loadSounds(cfg.SynthSounds)
+		if err != nil {
+			log.Printf("audio: reloading sounds: %v", err)
+			return
+		}
+		if !p.commands.push(command{typ: commandSetSounds, sounds: soundBuffers}) {
+			log.Printf("audio: command queue full, dropping sounds")
+		}
+	}
+
+	ToggleCapture = func() {
+		if c == nil {
+			name := fmt.Sprintf("blockcillin-%s.wav", time.Now().Format("20060102-150405"))
+			if err := startCapture(name); err != nil {
+				log.Printf("audio: capture %s: %v", name, err)
+			}
+			return
+		}
+
+		if !p.stopCapture() {
+			log.Printf("audio: command queue full, still capturing")
+			return
+		}
+
+		// The render callback closes the capture, which finishes its file in the background.
+		stopped = append(stopped, c)
+		c = nil
+	}
+
+	Terminate = func() {
+		// Ignore any sounds or further calls once shutting down.
+		Play = func(s Sound, tick int) {}
+		PlayAt = func(s Sound, pos Position, pitch float32, tick int) {}
+		PlayMusic = func(m Music) {}
+		SetPaused = func(paused bool) {}
+		FadeOut = func() {}
+		ReloadSounds = func() {}
+		ToggleCapture = func() {}
+		Terminate = func() {}
+
+		// Stop the backend anyway if it is no longer rendering, so the game can still exit.
+		if !p.finish(finishTimeout) {
+			log.Printf("audio: backend stopped rendering, not waiting for the fade out")
+		}
+		if err := b.stop(); err != nil {
+			log.Printf("audio: stopping backend: %v", err)
+		}
+		if stopMusic != nil {
+			stopMusic()
+		}
 
+		// Render is no longer called, so close any capture including one whose start or stop
+		// was still queued when the player quit, and wait for every capture's file to finish.
+		p.runCommands()
+		p.endCapture()
+		if c != nil {
+			stopped = append(stopped, c)
+		}
+		for _, cp := range stopped {
+			cp.wait()
+		}
+	}
+
+	return nil
+}
+
+// loadSounds decodes the recorded sounds or renders the synthesized sounds
+// from the current theme's assets and returns their samples indexed by Sound.
+func loadSounds(synthSounds bool) ([][]float32, error) {
 	var err error
-	makeBuffer := func(name string) []int16 {
+	makeBuffer := func(name string) []float32 {
 		if err != nil {
 			return nil
 		}
 
-		var r io.Reader
+		var r asset.ReadSeekCloser
 		if r, err = asset.Reader(name); err != nil {
 			return nil
 		}
+		defer r.Close()
+
+		if path.Ext(name) == ".sfx" {
+			var s *synth
+			if s, err = decodeSynth(r); err != nil {
+				err = fmt.Errorf("%s: %v", name, err)
+				return nil
+			}
+			return s.render()
+		}
 
 		var w *wav
 		if w, err = decodeWAV(r); err != nil {
+			err = fmt.Errorf("%s: %v", name, err)
 			return nil
 		}
 
 		log.Printf("%s: %+v", name, w)
-		buf := make([]int16, len(w.data)/2)
-		for i := 0; i < len(buf); i++ {
-			buf[i] = int16(w.data[i*2])
-			buf[i] += int16(w.data[i*2+1]) << 8
-		}
-		return buf
+		return w.samples()
 	}
 
-	var soundBuffers [][]int16
-	for _, a := range soundAssets {
+	assets := soundAssets
+	if synthSounds {
+		assets = soundSynthAssets
+	}
+
+	var soundBuffers [][]float32
+	for _, a := range assets {
 		soundBuffers = append(soundBuffers, makeBuffer(a))
 	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return soundBuffers, nil
+}
+
+// player queues sounds and music from the game and renders them for a backend.
+type player struct {
+	// m is the mixer that only the backend's render calls may use.
+	m *mixer
+
+	// soundBuffers maps Sound to its samples.
+	soundBuffers [][]float32
+
+	// commands has the commands from the game to run on the next render.
+	commands commandQueue
+
+	// quit is set atomically to 1 to fade out everything and stop accepting commands.
+	quit int32
+
+	// quitting is set once render has seen quit.
+	quitting bool
+
+	// done is closed by render once nothing is left to play after quitting.
+	done chan bool
+
+	// finished is set once render has closed done.
+	finished bool
+
+	// lastTicks maps Sound to the tick of the game update that it last started playing for.
+	lastTicks []int
+
+	// capture is where the mixed samples are teed to or nil if not capturing.
+	capture *capture
+
+	// soundVoices are the voices that sounds are played with. They are reused,
+	// so that playing sounds in the render callback never allocates.
+	soundVoices [soundVoiceCount]soundVoice
+}
+
+// soundVoiceCount is how many voices the player has to play sounds with. It has room for
+// maxVoices playing voices and as many stolen voices still fading out.
+const soundVoiceCount = 2 * maxVoices
+
+// soundVoice is a reusable voice of a sound with its samples and the pitch shifter that plays them.
+type soundVoice struct {
+	voice
+
+	// samples is the source of the sound's samples.
+	samples buffer
+
+	// shifter plays the samples at the sound's pitch unless it is 1.
+	shifter resampler
+
+	// playing is set while the mixer plays the voice.
+	playing bool
+}
+
+func newPlayer(soundBuffers [][]float32) *player {
+	p := &player{
+		// Make room for every sound voice and plenty of music voices crossfading,
+		// so that adding voices in the render callback does not grow the slice.
+		m:            &mixer{voices: make([]*voice, 0, 2*soundVoiceCount)},
+		soundBuffers: soundBuffers,
+		done:         make(chan bool),
+		lastTicks:    make([]int, len(soundBuffers)),
+	}
+	for i := range p.lastTicks {
+		p.lastTicks[i] = -soundRepeatTicks
+	}
+	for i := range p.soundVoices {
+		p.soundVoices[i].shifter.reset(nil, 1)
 	}
+	return p
+}
 
-	soundQueue := make(chan Sound, soundQueueSize)
-	Play = func(s Sound) {
-		soundQueue <- s
+// soundRequest is a request to play a sound from a position or nil for the center
+// for the game update with the tick.
+type soundRequest struct {
+	sound Sound
+	pos   *Position
+	pitch float32
+	tick  int
+}
+
+// play queues the sound to start on the next render.
+// The sound is dropped rather than blocking the game if too many commands are queued.
+func (p *player) play(s Sound, pos *Position, pitch float32, tick int) {
+	p.commands.push(command{
+		typ:   commandPlaySound,
+		sound: soundRequest{s, pos, pitch, tick},
+	})
+}
+
+// playMusic queues a crossfade to the given source on the next render.
+func (p *player) playMusic(src source) {
+	if !p.commands.push(command{typ: commandPlayMusic, music: src}) {
+		log.Printf("audio: command queue full, dropping music")
 	}
+}
 
-	done := make(chan bool)
-	Terminate = func() {
-		done <- true
-		<-done
-		close(done)
-		logFatalIfErr("portaudio.Terminate", portaudio.Terminate())
-	}
-
-	go func() {
-		const (
-			numInputChannels  = 0     /* zero input - no recording */
-			numOutputChannels = 2     /* stereo output */
-			sampleRate        = 44100 /* samples per second */
-			intervalMs        = 100
-			framesPerBuffer   = sampleRate / 1000.0 * intervalMs /*  len(buf) == numChannels * framesPerBuffer */
-			outputBufferSize  = numOutputChannels * framesPerBuffer
-		)
-
-		// Temporary buffers to read and write the next audio batch.
-		tmpIn := make([]int16, outputBufferSize)
-		tmpOut := make([]int16, outputBufferSize)
-
-		// outputRingBuffer is a buffer that the PortAudio callback will read data from,
-		// and that we will periodically wake up to write new data to.
-		outputRingBuffer := newRingBuffer(outputBufferSize * 10)
-
-		// process is the callback that PortAudio will call when it needs audio data.
-		process := func(out []int16) {
-			outputRingBuffer.pop(tmpIn)
-			for i := 0; i < len(out); i++ {
-				out[i] = tmpIn[i]
-			}
-		}
+// startCapture queues teeing the mixed samples to c starting on the next render.
+// It returns false if the command could not be queued.
+func (p *player) startCapture(c *capture) bool {
+	return p.commands.push(command{typ: commandStartCapture, capture: c})
+}
 
-		stream, err := portaudio.OpenDefaultStream(numInputChannels, numOutputChannels, sampleRate, framesPerBuffer, process)
-		logFatalIfErr("portaudio.OpenDefaultStream", err)
-		defer func() {
-			logFatalIfErr("stream.Close", stream.Close())
-		}()
-
-		logFatalIfErr("stream.Start()", stream.Start())
-		defer func() {
-			// stream.Stop blocks until all samples have been played.
-			logFatalIfErr("stream.Stop", stream.Stop())
-			done <- true
-		}()
-
-		var active [][]int16
-		quit := false
-
-	loop:
-		for {
-			select {
-			case <-time.After(intervalMs * time.Millisecond):
-				// Play whatever sounds are in the queue at the time.
-				n := len(soundQueue)
-				for i := 0; i < n; i++ {
-					active = append(active, soundBuffers[<-soundQueue])
-				}
-
-				// Fill temporary buffer with any active sounds buffers.
-				for i := 0; i < len(tmpOut); i++ {
-					// Combine active signals together.
-					var v int16
-					for j := 0; j < len(active); j++ {
-						// Remove any buffers if they have no more samples.
-						if len(active[j]) == 0 {
-							active = append(active[:j], active[j+1:]...)
-							j--
-							continue
-						}
-						v += active[j][0]
-						active[j] = active[j][1:]
-					}
-					tmpOut[i] = v
-				}
-				outputRingBuffer.push(tmpOut...)
-
-				// Only quit waking until there are no more streams to play.
-				if quit && len(active) == 0 {
-					break loop
-				}
-
-			case <-done:
-				close(soundQueue) // Prevent any new sounds from being scheduled.
-				quit = true
-			}
+// stopCapture queues stopping the current capture on the next render.
+// It returns false if the command could not be queued.
+func (p *player) stopCapture() bool {
+	return p.commands.push(command{typ: commandStopCapture})
+}
+
+// finishTimeout is how long Terminate waits for everything to fade out. It leaves plenty of room
+// past the fade for the backend's buffers but does not hang if the backend stopped calling render.
+const finishTimeout = exitFadeFrames*time.Second/sampleRate + time.Second
+
+// finish fades out all the sounds and music and blocks until they have finished playing.
+// It returns false if they have not finished within the timeout.
+func (p *player) finish(timeout time.Duration) bool {
+	atomic.StoreInt32(&p.quit, 1)
+	select {
+	case <-p.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// render fills out with the next interleaved stereo samples. Backends call it whenever
+// they need more, possibly from the output device's callback, so it never blocks.
+func (p *player) render(out []int16) {
+	if !p.quitting {
+		if atomic.LoadInt32(&p.quit) == 1 {
+			p.quitting = true
+			p.m.fadeOut(exitFadeFrames) // Fade out everything including the music since it never ends.
+		} else {
+			p.runCommands()
 		}
-	}()
+	}
+
+	p.m.mix(out)
+	if p.capture != nil {
+		p.capture.write(out)
+	}
+
+	// Only signal being done once there are no more voices to play.
+	if p.quitting && !p.m.active() && !p.finished {
+		p.finished = true
+		p.endCapture()
+		close(p.done)
+	}
+}
+
+// playSound starts playing the requested sound unless the same sound started for the same update.
+func (p *player) playSound(r soundRequest) {
+	// Play identical sounds like many blocks landing at once only once. Count game updates
+	// rather than rendered frames, since one render can cover many updates with large buffers.
+	if r.tick-p.lastTicks[r.sound] < soundRepeatTicks {
+		return
+	}
+
+	sv := p.freeSoundVoice()
+	if sv == nil {
+		return
+	}
+
+	sv.samples = buffer{p.soundBuffers[r.sound]}
+	var src source = &sv.samples
+	if r.pitch != 1 {
+		sv.shifter.reset(src, float64(r.pitch))
+		src = &sv.shifter
+	}
+
+	sv.voice = voice{
+		src:      src,
+		bus:      BusSFX,
+		gain:     soundGains[r.sound],
+		pos:      r.pos,
+		priority: soundPriorities[r.sound],
+		fade:     1,
+	}
+	if p.m.play(&sv.voice) {
+		sv.playing = true
+		p.lastTicks[r.sound] = r.tick
+	}
+}
 
+// freeSoundVoice returns a sound voice that the mixer is not playing or nil if all of them are.
+// The mixer removes voices as soon as they are done, so done voices are free.
+func (p *player) freeSoundVoice() *soundVoice {
+	for i := range p.soundVoices {
+		sv := &p.soundVoices[i]
+		if !sv.playing || sv.done {
+			sv.playing = false
+			return sv
+		}
+	}
 	return nil
 }
 
-func logFatalIfErr(tag string, err error) {
-	if err != nil {
-		log.Fatalf("%s: %v", tag, err)
+// runCommands runs all the commands queued since the last render in order.
+func (p *player) runCommands() {
+	for {
+		c, ok := p.commands.pop()
+		if !ok {
+			return
+		}
+
+		switch c.typ {
+		case commandPlaySound:
+			p.playSound(c.sound)
+
+		case commandPlayMusic:
+			p.m.playMusic(c.music)
+
+		case commandPause:
+			p.m.pause()
+
+		case commandResume:
+			p.m.resume()
+
+		case commandFadeOut:
+			p.m.fadeOut(exitFadeFrames)
+
+		case commandStartCapture:
+			p.endCapture()
+			p.capture = c.capture
+
+		case commandStopCapture:
+			p.endCapture()
+
+		case commandSetSounds:
+			p.soundBuffers = c.sounds
+		}
+	}
+}
+
+// endCapture closes the current capture if there is one.
+func (p *player) endCapture() {
+	if p.capture != nil {
+		p.capture.close()
+		p.capture = nil
 	}
 }
@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	// nullFramesPerBuffer is how many frames the null backend renders at a time if not configured.
+	nullFramesPerBuffer = 512
+
+	// fileFramesPerBuffer is how many frames the file backend renders at a time.
+	fileFramesPerBuffer = 1024
+)
+
+// backend is an output device that plays the mixed audio.
+type backend interface {
+	// start starts playing the interleaved stereo samples that render fills buffers with.
+	// The backend calls render whenever it needs more samples but never concurrently.
+	start(render func(out []int16)) error
+
+	// stop stops calling render, waits for the rendered samples to play, and releases the device.
+	stop() error
+}
+
+// nullBackend is a backend that renders samples in real time but discards them.
+// It is used when there is no output device so the game still runs normally.
+type nullBackend struct {
+	// framesPerBuffer is how many frames to render at a time or 0 for nullFramesPerBuffer.
+	framesPerBuffer int
+
+	// quit is used to stop the render goroutine and wait for it to exit.
+	quit chan bool
+}
+
+func (b *nullBackend) start(render func(out []int16)) error {
+	framesPerBuffer := b.framesPerBuffer
+	if framesPerBuffer <= 0 {
+		framesPerBuffer = nullFramesPerBuffer
+	}
+
+	b.quit = make(chan bool)
+	go func() {
+		out := make([]int16, framesPerBuffer*numOutputChannels)
+		t := time.NewTicker(time.Duration(framesPerBuffer) * time.Second / sampleRate)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				render(out)
+			case <-b.quit:
+				b.quit <- true
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *nullBackend) stop() error {
+	b.quit <- true
+	<-b.quit
+	return nil
+}
+
+// fileBackend is a backend that writes the samples to a WAV file instead of playing them.
+// It only renders samples when advance is called so its output is deterministic.
+type fileBackend struct {
+	// ws is where the WAV file is written to.
+	ws io.WriteSeeker
+
+	// w writes the WAV file to ws once started.
+	w *wavWriter
+
+	// render is the function passed to start.
+	render func(out []int16)
+
+	// buf is a temporary buffer to render samples into.
+	buf []int16
+}
+
+func newFileBackend(ws io.WriteSeeker) *fileBackend {
+	return &fileBackend{
+		ws:  ws,
+		buf: make([]int16, fileFramesPerBuffer*numOutputChannels),
+	}
+}
+
+func (b *fileBackend) start(render func(out []int16)) error {
+	w, err := newWAVWriter(b.ws)
+	if err != nil {
+		return err
+	}
+	b.w = w
+	b.render = render
+	return nil
+}
+
+// advance renders the given number of frames and writes them to the file.
+func (b *fileBackend) advance(numFrames int) error {
+	for numFrames > 0 {
+		n := numFrames
+		if n > fileFramesPerBuffer {
+			n = fileFramesPerBuffer
+		}
+		out := b.buf[:n*numOutputChannels]
+		b.render(out)
+		if err := b.w.write(out); err != nil {
+			return err
+		}
+		numFrames -= n
+	}
+	return nil
+}
+
+func (b *fileBackend) stop() error {
+	return b.w.close()
+}
@@ -0,0 +1,139 @@
+package audio
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileBackend(t *testing.T) {
+	f, err := ioutil.TempFile("", "blockcillin-audio")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	p := newPlayer([][]float32{
+		SoundMove:   {0.5, -0.5, 0.25, -0.25},
+		SoundSelect: {0.5, 0.5},
+	})
+	SetVolume(BusSFX, 1)
+
+	b := newFileBackend(f)
+	if err := b.start(p.render); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	p.play(SoundMove, nil, 1, 0)
+	if err := b.advance(3); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	p.play(SoundSelect, nil, 1, 0)
+	if err := b.advance(2); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	if err := b.stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	r, err := os.Open(f.Name())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+
+	w, err := decodeWAV(r)
+	if err != nil {
+		t.Fatalf("decodeWAV: %v", err)
+	}
+
+	var got []int16
+	for i := 0; i < len(w.data); i += 2 {
+		got = append(got, int16(w.data[i])|int16(w.data[i+1])<<8)
+	}
+
+	g := soundGains[SoundMove]
+	want := []int16{
+		floatToInt16(0.5 * g), floatToInt16(-0.5 * g),
+		floatToInt16(0.25 * g), floatToInt16(-0.25 * g),
+		0, 0,
+		floatToInt16(0.5 * soundGains[SoundSelect]), floatToInt16(0.5 * soundGains[SoundSelect]),
+		0, 0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("file samples = %v, want %v", got, want)
+	}
+}
+
+func TestRepeatedSounds(t *testing.T) {
+	p := newPlayer([][]float32{
+		SoundMove: make([]float32, 1000*numOutputChannels),
+	})
+	out := make([]int16, numOutputChannels)
+
+	// Sounds in the same update should only play once.
+	for i := 0; i < 3; i++ {
+		p.play(SoundMove, nil, 1, 0)
+	}
+	p.render(out)
+	if got := len(p.m.voices); got != 1 {
+		t.Errorf("voices after repeated sounds = %d, want 1", got)
+	}
+
+	// Sounds in later updates should play again even if they are rendered together
+	// like they are with a large output buffer.
+	p.play(SoundMove, nil, 1, 1)
+	p.play(SoundMove, nil, 1, 2)
+	p.render(out)
+	if got := len(p.m.voices); got != 3 {
+		t.Errorf("voices after next updates = %d, want 3", got)
+	}
+}
+
+func TestPlaySoundReusesVoices(t *testing.T) {
+	p := newPlayer([][]float32{
+		SoundMove: make([]float32, 100*numOutputChannels),
+	})
+	out := make([]int16, 200*numOutputChannels)
+	p.render(out)
+
+	// Playing pitched sounds reuses the voices and their pitch shifters instead of allocating.
+	tick := 0
+	if n := testing.AllocsPerRun(100, func() {
+		tick++
+		p.play(SoundMove, nil, 1.5, tick)
+		p.render(out)
+	}); n != 0 {
+		t.Errorf("allocations per played sound = %v, want 0", n)
+	}
+
+	// Sounds are dropped when every voice is playing.
+	for i := range p.soundVoices {
+		p.soundVoices[i].playing = true
+		p.soundVoices[i].done = false
+	}
+	p.play(SoundMove, nil, 1, tick+1)
+	p.runCommands()
+	if got := len(p.m.voices); got != 0 {
+		t.Errorf("voices without a free voice = %d, want 0", got)
+	}
+
+	// Done voices are free again.
+	p.soundVoices[0].done = true
+	p.play(SoundMove, nil, 1, tick+2)
+	p.runCommands()
+	if got := len(p.m.voices); got != 1 {
+		t.Errorf("voices with a done voice = %d, want 1", got)
+	}
+}
+
+func TestFinishTimeout(t *testing.T) {
+	p := newPlayer(nil)
+
+	// Finishing gives up instead of hanging when the backend stopped calling render.
+	if p.finish(10 * time.Millisecond) {
+		t.Errorf("finish() = true without rendering, want false")
+	}
+}
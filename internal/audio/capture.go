@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"io"
+	"log"
+	"sync/atomic"
+)
+
+const (
+	// captureBuffers is how many buffers of samples can wait to be written to a capture file.
+	captureBuffers = 64
+
+	// captureFramesPerBuffer is how many frames each capture buffer holds
+	// when the output device chooses how many frames to render on each callback.
+	captureFramesPerBuffer = 4096
+)
+
+// capture tees the mixed samples to a WAV file. The render callback hands it copies of
+// what it mixed and a goroutine writes them so the callback never waits on the disk.
+type capture struct {
+	// w writes the WAV file.
+	w *wavWriter
+
+	// name is the name of the file for logging.
+	name string
+
+	// chunks are the buffers of samples waiting to be written.
+	chunks chan []int16
+
+	// free are the buffers that can be filled with samples. They are all allocated
+	// up front, so the render callback never allocates.
+	free chan []int16
+
+	// dropped is how many buffers were dropped because the writer fell behind.
+	// It is accessed atomically since the render callback changes it.
+	dropped int32
+
+	// done receives the result of writing the file once it is closed.
+	done chan error
+}
+
+// newCapture writes a WAV header to ws and starts the goroutine that writes samples after it.
+// Its buffers hold framesPerBuffer frames or captureFramesPerBuffer if it is 0.
+func newCapture(ws io.WriteSeeker, name string, framesPerBuffer int) (*capture, error) {
+	w, err := newWAVWriter(ws)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &capture{
+		w:      w,
+		name:   name,
+		chunks: make(chan []int16, captureBuffers),
+		free:   make(chan []int16, captureBuffers),
+		done:   make(chan error, 1),
+	}
+
+	if framesPerBuffer <= 0 {
+		framesPerBuffer = captureFramesPerBuffer
+	}
+	for i := 0; i < captureBuffers; i++ {
+		c.free <- make([]int16, 0, framesPerBuffer*numOutputChannels)
+	}
+
+	go c.run()
+	return c, nil
+}
+
+// run writes the buffers of samples until the capture is closed.
+func (c *capture) run() {
+	var err error
+	for buf := range c.chunks {
+		// Keep draining after an error so the render callback can still hand off buffers.
+		if err == nil {
+			err = c.w.write(buf)
+		}
+		c.free <- buf
+	}
+
+	if cerr := c.w.close(); err == nil {
+		err = cerr
+	}
+	if n := atomic.LoadInt32(&c.dropped); n > 0 {
+		log.Printf("audio: capture %s dropped %d buffers", c.name, n)
+	}
+	if err != nil {
+		log.Printf("audio: capture %s: %v", c.name, err)
+	} else {
+		log.Printf("audio: captured %s", c.name)
+	}
+	c.done <- err
+}
+
+// write queues a copy of the samples to be written. It never blocks or allocates and
+// drops the samples if the writer has fallen behind. Samples that do not fit in one buffer
+// are split across several. It must only be called by the render callback.
+func (c *capture) write(samples []int16) {
+	for len(samples) > 0 {
+		var buf []int16
+		select {
+		case buf = <-c.free:
+		default:
+			atomic.AddInt32(&c.dropped, 1)
+			return
+		}
+
+		n := cap(buf)
+		if n > len(samples) {
+			n = len(samples)
+		}
+
+		// The send does not block, since the channel holds as many buffers as there are.
+		c.chunks <- append(buf[:0], samples[:n]...)
+		samples = samples[n:]
+	}
+}
+
+// close stops accepting samples and finishes the file in the background.
+// It must only be called by the render callback after its last call to write.
+func (c *capture) close() {
+	close(c.chunks)
+}
+
+// wait blocks until the file is finished and returns any error writing it.
+func (c *capture) wait() error {
+	return <-c.done
+}
@@ -0,0 +1,179 @@
+package audio
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCapture(t *testing.T) {
+	f, err := ioutil.TempFile("", "blockcillin-capture")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	p := newPlayer([][]float32{
+		SoundMove: {0.5, -0.5, 0.25, -0.25},
+	})
+	SetVolume(BusSFX, 1)
+
+	c, err := newCapture(f, f.Name(), 0)
+	if err != nil {
+		t.Fatalf("newCapture: %v", err)
+	}
+
+	out := make([]int16, 2*numOutputChannels)
+
+	// Samples before the capture starts are not captured.
+	p.play(SoundMove, nil, 1, 0)
+	p.render(out)
+
+	var want []int16
+	p.startCapture(c)
+	for i := 0; i < 3; i++ {
+		p.play(SoundMove, nil, 1, i+1)
+		p.render(out)
+		want = append(want, out...)
+	}
+	p.stopCapture()
+	p.render(out)
+
+	if err := c.wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	r, err := os.Open(f.Name())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+
+	w, err := decodeWAV(r)
+	if err != nil {
+		t.Fatalf("decodeWAV: %v", err)
+	}
+
+	var got []int16
+	for i := 0; i < len(w.data); i += 2 {
+		got = append(got, int16(w.data[i])|int16(w.data[i+1])<<8)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("captured samples = %v, want %v", got, want)
+	}
+}
+
+func TestCaptureFinish(t *testing.T) {
+	f, err := ioutil.TempFile("", "blockcillin-capture")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	p := newPlayer(nil)
+	c, err := newCapture(f, f.Name(), 0)
+	if err != nil {
+		t.Fatalf("newCapture: %v", err)
+	}
+	p.startCapture(c)
+
+	out := make([]int16, numOutputChannels)
+	p.render(out)
+
+	// The capture should be finished along with the player.
+	go func() {
+		for !p.finished {
+			p.render(out)
+		}
+	}()
+	if !p.finish(time.Second) {
+		t.Fatalf("finish() = false, want true")
+	}
+
+	if err := c.wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if p.capture != nil {
+		t.Errorf("capture = %v, want nil", p.capture)
+	}
+}
+
+func TestCaptureSplitsSamples(t *testing.T) {
+	f, err := ioutil.TempFile("", "blockcillin-capture")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	// Samples longer than a buffer are split across several buffers instead of being dropped.
+	c, err := newCapture(f, f.Name(), 1)
+	if err != nil {
+		t.Fatalf("newCapture: %v", err)
+	}
+	want := []int16{1, 2, 3, 4, 5, 6}
+	c.write(want)
+	c.close()
+	if err := c.wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	r, err := os.Open(f.Name())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+
+	w, err := decodeWAV(r)
+	if err != nil {
+		t.Fatalf("decodeWAV: %v", err)
+	}
+
+	var got []int16
+	for i := 0; i < len(w.data); i += 2 {
+		got = append(got, int16(w.data[i])|int16(w.data[i+1])<<8)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("captured samples = %v, want %v", got, want)
+	}
+	if n := atomic.LoadInt32(&c.dropped); n != 0 {
+		t.Errorf("dropped = %d, want 0", n)
+	}
+}
+
+func TestCaptureDrops(t *testing.T) {
+	f, err := ioutil.TempFile("", "blockcillin-capture")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	c, err := newCapture(f, f.Name(), 1)
+	if err != nil {
+		t.Fatalf("newCapture: %v", err)
+	}
+
+	// Samples are dropped when every buffer is waiting to be written.
+	var taken [][]int16
+	for i := 0; i < captureBuffers; i++ {
+		taken = append(taken, <-c.free)
+	}
+	c.write(make([]int16, numOutputChannels))
+
+	if got := atomic.LoadInt32(&c.dropped); got != 1 {
+		t.Errorf("dropped = %d, want 1", got)
+	}
+	if got := len(c.chunks); got != 0 {
+		t.Errorf("chunks = %d, want 0", got)
+	}
+
+	for _, buf := range taken {
+		c.free <- buf
+	}
+	c.close()
+	if err := c.wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+const (
+	// chainSemitones is how many semitones higher clear sounds start with each chain level.
+	chainSemitones = 3
+
+	// maxClearSemitones is the highest clear sounds can be raised in semitones.
+	maxClearSemitones = 24
+
+	// speedPerMusicLevel is how much the board's speed must increase to add a music layer.
+	speedPerMusicLevel = 10
+
+	// stingerChainLevel is the chain level from which matches play the music's stinger.
+	stingerChainLevel = 2
+)
+
+// eventSounds maps game events to the sounds they play.
+var eventSounds = map[game.EventType]Sound{
+	game.EventMove:       SoundMove,
+	game.EventSwap:       SoundSwap,
+	game.EventClear:      SoundClear,
+	game.EventLand:       SoundThud,
+	game.EventPause:      SoundSelect,
+	game.EventContinue:   SoundSelect,
+	game.EventMenuMove:   SoundMove,
+	game.EventMenuSelect: SoundSelect,
+}
+
+// positionedEvents are the events at cells on the board whose sounds come from their cells.
+var positionedEvents = map[game.EventType]bool{
+	game.EventSwap:  true,
+	game.EventClear: true,
+	game.EventLand:  true,
+}
+
+// stateMusic maps game states to the music to play in them.
+var stateMusic = [...]Music{
+	game.GameInitial: MusicMenu,
+	game.GamePlaying: MusicGame,
+	game.GamePaused:  MusicGame, // Keep the paused game music to resume it where it left off.
+	game.GameExiting: MusicNone,
+}
+
+// HandleEvent plays the sound or music for the game event if it has any.
+// Pass it to game.Subscribe to play sounds as the game is played.
+func HandleEvent(e game.Event) {
+	if s, ok := eventSounds[e.Type]; ok {
+		if positionedEvents[e.Type] {
+			PlayAt(s, Position{X: e.X, Y: e.Y}, eventPitch(e), e.Tick)
+		} else {
+			Play(s, e.Tick)
+		}
+	}
+
+	switch e.Type {
+	case game.EventStateChange:
+		if e.State == game.GameExiting {
+			FadeOut()
+			break
+		}
+		SetPaused(e.State == game.GamePaused)
+		PlayMusic(stateMusic[e.State])
+
+	case game.EventGameOver:
+		PlayMusic(MusicNone)
+
+	case game.EventMove:
+		MoveListener(e.X)
+
+	case game.EventNewBoard:
+		SetListener(e.X, e.CellCount)
+		SetMusicIntensity(e.Speed / speedPerMusicLevel)
+		SetMusicTense(false)
+
+	case game.EventSpeedUp:
+		SetMusicIntensity(e.Speed / speedPerMusicLevel)
+
+	case game.EventDanger:
+		SetMusicTense(true)
+
+	case game.EventSafe:
+		SetMusicTense(false)
+
+	case game.EventMatch:
+		if e.ChainLevel >= stingerChainLevel {
+			PlayStinger()
+		}
+
+	case game.EventThemeChange:
+		ReloadSounds()
+	}
+}
+
+// eventPitch returns the pitch ratio to play the event's sound at.
+// Each block in a match pops a semitone higher than the one before it,
+// so bigger combos climb higher, and each chain level starts the ladder higher.
+func eventPitch(e game.Event) float32 {
+	if e.Type != game.EventClear {
+		return 1
+	}
+
+	semitones := chainSemitones*e.ChainLevel + e.ClearIndex
+	if semitones > maxClearSemitones {
+		semitones = maxClearSemitones
+	}
+	return float32(math.Pow(2, float64(semitones)/12))
+}
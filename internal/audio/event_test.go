@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+func TestEventPitch(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		event game.Event
+		want  float32
+	}{
+		{
+			desc:  "not a clear",
+			event: game.Event{Type: game.EventSwap, ChainLevel: 3},
+			want:  1,
+		},
+		{
+			desc:  "first block of first chain",
+			event: game.Event{Type: game.EventClear, ComboLevel: 3},
+			want:  1,
+		},
+		{
+			desc:  "third block rises two semitones",
+			event: game.Event{Type: game.EventClear, ComboLevel: 3, ClearIndex: 2},
+			want:  1.1225,
+		},
+		{
+			desc:  "chain starts an octave higher",
+			event: game.Event{Type: game.EventClear, ComboLevel: 3, ChainLevel: 4},
+			want:  2,
+		},
+		{
+			desc:  "capped at two octaves",
+			event: game.Event{Type: game.EventClear, ComboLevel: 10, ChainLevel: 7, ClearIndex: 9},
+			want:  4,
+		},
+	} {
+		got := float32(math.Floor(float64(eventPitch(tt.event))*1e4+0.5) / 1e4)
+		if got != tt.want {
+			t.Errorf("[%s] eventPitch() = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestHandleEventListener(t *testing.T) {
+	defer SetListener(0, 0)
+
+	HandleEvent(game.Event{Type: game.EventNewBoard, X: 1, CellCount: 4})
+	if x, n := atomic.LoadInt32(&listenerX), atomic.LoadInt32(&listenerCellCount); x != 1 || n != 4 {
+		t.Errorf("listener after EventNewBoard = %d, %d, want 1, 4", x, n)
+	}
+
+	HandleEvent(game.Event{Type: game.EventMove, X: 3})
+	if x, n := atomic.LoadInt32(&listenerX), atomic.LoadInt32(&listenerCellCount); x != 3 || n != 4 {
+		t.Errorf("listener after EventMove = %d, %d, want 3, 4", x, n)
+	}
+
+	// Sounds at cells do not move the listener.
+	HandleEvent(game.Event{Type: game.EventSwap, X: 0})
+	if x := atomic.LoadInt32(&listenerX); x != 3 {
+		t.Errorf("listener after EventSwap = %d, want 3", x)
+	}
+}
@@ -0,0 +1,337 @@
+package audio
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Bus is an enum that identifies a group of sounds that share a volume.
+//go:generate stringer -type=Bus
+type Bus int
+
+const (
+	// BusMaster controls the volume of all sounds.
+	BusMaster Bus = iota
+
+	// BusSFX controls the volume of the sound effects.
+	BusSFX
+
+	// BusMusic controls the volume of the music.
+	BusMusic
+
+	numBuses = iota
+)
+
+// busVolumes are the volumes of each bus stored as float32 bits.
+// They are accessed atomically since the mixer reads them while the game changes them.
+var busVolumes [numBuses]uint32
+
+func init() {
+	for b := range busVolumes {
+		SetVolume(Bus(b), 1)
+	}
+}
+
+// SetVolume sets the volume of the bus from 0 to 1. It is safe to call at any time.
+func SetVolume(b Bus, volume float32) {
+	switch {
+	case volume < 0:
+		volume = 0
+	case volume > 1:
+		volume = 1
+	}
+	atomic.StoreUint32(&busVolumes[b], math.Float32bits(volume))
+}
+
+// Volume returns the volume of the bus from 0 to 1.
+func Volume(b Bus) float32 {
+	return math.Float32frombits(atomic.LoadUint32(&busVolumes[b]))
+}
+
+// soundGains maps Sound to a gain to balance the sounds against each other.
+var soundGains = [...]float32{
+	SoundMove:   0.6,
+	SoundSelect: 0.8,
+	SoundSwap:   0.7,
+	SoundClear:  0.8,
+	SoundThud:   0.7,
+}
+
+// soundPriorities maps Sound to how important it is to keep playing when voices run out.
+// Higher priority sounds steal voices from lower or equal priority sounds.
+var soundPriorities = [...]int{
+	SoundMove:   1,
+	SoundSelect: 3,
+	SoundSwap:   2,
+	SoundClear:  2,
+	SoundThud:   0,
+}
+
+// maxVoices is the most sound effects that can play at once not counting the music.
+const maxVoices = 16
+
+// stealFadeFrames is how many frames it takes a stolen voice to fade out without clicking.
+const stealFadeFrames = 64
+
+// softClipThreshold is the level above which mixed samples are smoothly compressed.
+const softClipThreshold = 0.8
+
+// crossfadeFrames is how many frames it takes to fade between music tracks.
+const crossfadeFrames = sampleRate
+
+// pauseFadeFrames is how many frames it takes voices to fade out when paused and back in when resumed.
+const pauseFadeFrames = sampleRate / 10
+
+// exitFadeFrames is how many frames it takes everything to fade out when the game exits.
+// It matches how long the game takes to exit.
+const exitFadeFrames = sampleRate / 2
+
+// source is something that produces interleaved stereo samples for a voice.
+type source interface {
+	// read fills buf with the next samples and returns how many were filled.
+	// It returns less than len(buf) when there are no more samples.
+	read(buf []float32) int
+}
+
+// buffer is a source of samples that are already in memory.
+type buffer struct {
+	samples []float32
+}
+
+func (b *buffer) read(buf []float32) int {
+	n := copy(buf, b.samples)
+	b.samples = b.samples[n:]
+	return n
+}
+
+// voice is a source being played by the mixer.
+type voice struct {
+	// src is the source of the voice's samples.
+	src source
+
+	// bus is the bus whose volume applies to the voice.
+	bus Bus
+
+	// gain is the voice's own volume.
+	gain float32
+
+	// pos is the cell the voice comes from or nil if it plays in the center.
+	pos *Position
+
+	// priority is how important the voice is to keep when another voice needs to be stolen.
+	priority int
+
+	// stolen is set when the voice is fading out to make room for another voice.
+	stolen bool
+
+	// paused is set when the voice is fading out to hold its place until it is resumed.
+	paused bool
+
+	// fade is the current fade level from 0 to 1 applied on top of the gain.
+	fade float32
+
+	// fadeDelta is added to fade on each frame until it reaches 0 or 1.
+	// The voice is removed if it fades out to 0 unless it is paused.
+	fadeDelta float32
+
+	// done is set when the voice has no more samples or has faded out.
+	done bool
+}
+
+// mixer mixes voices together into interleaved stereo output.
+type mixer struct {
+	// voices are the voices currently playing.
+	voices []*voice
+
+	// music is the voice playing the current music track or nil if none.
+	music *voice
+
+	// buf is a temporary buffer to mix samples into before clipping.
+	buf []float32
+
+	// voiceBuf is a temporary buffer to read each voice's samples into.
+	voiceBuf []float32
+}
+
+// play starts playing the voice. If maxVoices sound effects are already playing, it steals
+// the oldest voice with the lowest priority or drops the new voice if all of them have a
+// higher priority. It returns whether the voice was played.
+func (m *mixer) play(v *voice) bool {
+	var victim *voice
+	numVoices := 0
+	for _, w := range m.voices {
+		if w.bus == BusMusic || w.stolen {
+			continue
+		}
+		numVoices++
+		if victim == nil || w.priority < victim.priority {
+			victim = w
+		}
+	}
+
+	if numVoices >= maxVoices {
+		if victim.priority > v.priority {
+			return false
+		}
+		victim.stolen = true
+		victim.paused = false
+		victim.fadeDelta = -1.0 / stealFadeFrames
+	}
+
+	m.voices = append(m.voices, v)
+	return true
+}
+
+// playMusic crossfades from the current music to the given source. Nil stops the music.
+func (m *mixer) playMusic(src source) {
+	if m.music != nil {
+		m.music.paused = false
+		m.music.fadeDelta = -1.0 / crossfadeFrames
+		m.music = nil
+	}
+	if src == nil {
+		return
+	}
+	m.music = &voice{
+		src:       src,
+		bus:       BusMusic,
+		gain:      1,
+		fadeDelta: 1.0 / crossfadeFrames,
+	}
+	m.voices = append(m.voices, m.music)
+}
+
+// pause fades out the playing voices and holds them in place until resume is called.
+// Voices already fading out for good are left to finish, and voices played afterwards are not paused.
+func (m *mixer) pause() {
+	for _, v := range m.voices {
+		if v.fadeDelta < 0 {
+			continue
+		}
+		v.paused = true
+		v.fadeDelta = -1.0 / pauseFadeFrames
+	}
+}
+
+// resume fades the paused voices back in from where they were paused.
+func (m *mixer) resume() {
+	for _, v := range m.voices {
+		if v.paused {
+			v.paused = false
+			v.fadeDelta = 1.0 / pauseFadeFrames
+		}
+	}
+}
+
+// fadeOut fades out all the voices including the music and any paused voices over the given frames.
+// Voices already fading out faster keep doing so.
+func (m *mixer) fadeOut(numFrames int) {
+	for _, v := range m.voices {
+		v.paused = false
+		if d := -1 / float32(numFrames); v.fadeDelta > d {
+			v.fadeDelta = d
+		}
+	}
+	m.music = nil
+}
+
+// active returns whether the mixer has any voices left to play.
+func (m *mixer) active() bool {
+	return len(m.voices) > 0
+}
+
+// mix fills out with the next samples of the active voices.
+func (m *mixer) mix(out []int16) {
+	if len(m.buf) < len(out) {
+		m.buf = make([]float32, len(out))
+		m.voiceBuf = make([]float32, len(out))
+	}
+	buf := m.buf[:len(out)]
+	for i := range buf {
+		buf[i] = 0
+	}
+
+	master := Volume(BusMaster)
+
+	for i := 0; i < len(m.voices); i++ {
+		v := m.voices[i]
+		m.mixVoice(buf, v, v.gain*Volume(v.bus)*master)
+
+		// Remove any voices if they have no more samples.
+		if v.done {
+			m.voices = append(m.voices[:i], m.voices[i+1:]...)
+			i--
+		}
+	}
+
+	for i, v := range buf {
+		out[i] = floatToInt16(softClip(v))
+	}
+}
+
+// mixVoice adds the voice's next samples multiplied by the gain to buf.
+func (m *mixer) mixVoice(buf []float32, v *voice, gain float32) {
+	// Paused voices that have faded out stop reading so they resume where they left off.
+	if v.paused && v.fade <= 0 {
+		return
+	}
+
+	vbuf := m.voiceBuf[:len(buf)]
+	n := v.src.read(vbuf)
+	if n < len(vbuf) {
+		v.done = true
+	}
+
+	left, right := positionGains(v.pos)
+
+	for j := 0; j+1 < n; j += 2 {
+		switch v.fade += v.fadeDelta; {
+		case v.fade >= 1:
+			v.fade, v.fadeDelta = 1, 0
+
+		case v.fade <= 0:
+			v.fade = 0
+			if !v.paused {
+				v.done = true
+			}
+			return
+		}
+		g := gain * v.fade
+		buf[j] += vbuf[j] * g * left
+		buf[j+1] += vbuf[j+1] * g * right
+	}
+}
+
+// softClip smoothly compresses samples above softClipThreshold so they never exceed 1.
+func softClip(v float32) float32 {
+	a := v
+	if a < 0 {
+		a = -a
+	}
+	if a <= softClipThreshold {
+		return v
+	}
+
+	const headroom = 1 - softClipThreshold
+	a = softClipThreshold + headroom*float32(math.Tanh(float64((a-softClipThreshold)/headroom)))
+	if v < 0 {
+		return -a
+	}
+	return a
+}
+
+// floatToInt16 converts a sample from -1 to 1 into a 16-bit sample.
+func floatToInt16(v float32) int16 {
+	switch {
+	case v > 1:
+		v = 1
+	case v < -1:
+		v = -1
+	}
+	return int16(v * math.MaxInt16)
+}
+
+// int16ToFloat converts a 16-bit sample into a sample from -1 to 1.
+func int16ToFloat(v int16) float32 {
+	return float32(v) / -math.MinInt16
+}
@@ -0,0 +1,196 @@
+package audio
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMix(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		voices  []*voice
+		outSize int
+		want    []int16
+	}{
+		{
+			desc: "single voice",
+			voices: []*voice{
+				{src: &buffer{[]float32{0.5, -0.5}}, bus: BusSFX, gain: 1, fade: 1},
+			},
+			outSize: 4,
+			want:    []int16{16383, -16383, 0, 0},
+		},
+		{
+			desc: "gain",
+			voices: []*voice{
+				{src: &buffer{[]float32{0.5, -0.5}}, bus: BusSFX, gain: 0.5, fade: 1},
+			},
+			outSize: 4,
+			want:    []int16{8191, -8191, 0, 0},
+		},
+		{
+			desc: "overlapping voices do not wrap around",
+			voices: []*voice{
+				{src: &buffer{[]float32{0.9, -0.9}}, bus: BusSFX, gain: 1, fade: 1},
+				{src: &buffer{[]float32{0.9, -0.9}}, bus: BusSFX, gain: 1, fade: 1},
+				{src: &buffer{[]float32{0.9, -0.9}}, bus: BusSFX, gain: 1, fade: 1},
+			},
+			outSize: 4,
+			want:    []int16{floatToInt16(softClip(2.7)), floatToInt16(softClip(-2.7)), 0, 0},
+		},
+	} {
+		m := &mixer{voices: tt.voices}
+		got := make([]int16, tt.outSize)
+		m.mix(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] mix() = %v, want %v", tt.desc, got, tt.want)
+		}
+		if m.active() {
+			t.Errorf("[%s] active() = true, want false", tt.desc)
+		}
+	}
+}
+
+func TestPlayMusic(t *testing.T) {
+	ones := func() []float32 {
+		s := make([]float32, crossfadeFrames*numOutputChannels*2)
+		for i := range s {
+			s[i] = 0.5
+		}
+		return s
+	}
+
+	m := &mixer{}
+	m.playMusic(&buffer{ones()})
+	first := m.music
+
+	out := make([]int16, (crossfadeFrames+1000)*numOutputChannels)
+	m.mix(out)
+	if first.fade != 1 {
+		t.Errorf("fade after crossfade = %f, want 1", first.fade)
+	}
+	if out[0] >= out[len(out)-1] {
+		t.Errorf("mix() = %d...%d, want fade in", out[0], out[len(out)-1])
+	}
+
+	m.playMusic(nil)
+	m.mix(out)
+	if m.active() {
+		t.Errorf("active() = true after fading out, want false")
+	}
+}
+
+func TestSoftClip(t *testing.T) {
+	for _, v := range []float32{-10, -1, -0.5, 0, 0.5, 1, 10} {
+		got := softClip(v)
+		if got > 1 || got < -1 {
+			t.Errorf("softClip(%f) = %f, want within [-1, 1]", v, got)
+		}
+		if math.Abs(float64(v)) <= softClipThreshold && got != v {
+			t.Errorf("softClip(%f) = %f, want %f", v, got, v)
+		}
+		if (got < 0) != (v < 0) {
+			t.Errorf("softClip(%f) = %f, want same sign", v, got)
+		}
+	}
+}
+
+func TestPlayVoiceLimit(t *testing.T) {
+	m := &mixer{}
+	m.playMusic(&buffer{make([]float32, 100)})
+
+	var voices []*voice
+	for i := 0; i < maxVoices; i++ {
+		v := &voice{src: &buffer{}, bus: BusSFX, priority: 1, fade: 1}
+		if i == 3 {
+			v.priority = 0
+		}
+		if !m.play(v) {
+			t.Fatalf("play(%d) = false, want true", i)
+		}
+		voices = append(voices, v)
+	}
+
+	// The lowest priority voice should be stolen even though it is not the oldest.
+	if !m.play(&voice{src: &buffer{}, bus: BusSFX, priority: 1, fade: 1}) {
+		t.Errorf("play() with equal priority = false, want true")
+	}
+	if !voices[3].stolen || voices[0].stolen {
+		t.Errorf("stolen voices = %t, %t, want true, false", voices[3].stolen, voices[0].stolen)
+	}
+
+	// The oldest voice with the lowest priority should be stolen next.
+	if !m.play(&voice{src: &buffer{}, bus: BusSFX, priority: 2, fade: 1}) {
+		t.Errorf("play() with higher priority = false, want true")
+	}
+	if !voices[0].stolen {
+		t.Errorf("oldest voice not stolen")
+	}
+
+	// Voices should be dropped if every playing voice has a higher priority.
+	if m.play(&voice{src: &buffer{}, bus: BusSFX, priority: 0, fade: 1}) {
+		t.Errorf("play() with lower priority = true, want false")
+	}
+
+	if m.music.stolen {
+		t.Errorf("music voice stolen")
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	ramp := make([]float32, (pauseFadeFrames*4)*numOutputChannels)
+	for i := range ramp {
+		ramp[i] = float32(i/numOutputChannels) / float32(len(ramp))
+	}
+
+	m := &mixer{}
+	v := &voice{src: &buffer{ramp}, bus: BusSFX, gain: 1, fade: 1}
+	m.play(v)
+
+	// Mix a little longer than the fade to allow for rounding.
+	out := make([]int16, (pauseFadeFrames+10)*numOutputChannels)
+	m.mix(out)
+	m.pause()
+	m.mix(out)
+	if v.fade != 0 || !m.active() {
+		t.Fatalf("fade, active() after pausing = %f, %t, want 0, true", v.fade, m.active())
+	}
+	remaining := len(v.src.(*buffer).samples)
+
+	// Voices played while paused should play normally.
+	other := &voice{src: &buffer{[]float32{0.5, 0.5}}, bus: BusSFX, gain: 1, fade: 1}
+	m.play(other)
+	m.mix(out)
+	if out[0] == 0 {
+		t.Errorf("mix() while paused = %d, want sound from the new voice", out[0])
+	}
+	if got := len(v.src.(*buffer).samples); got != remaining {
+		t.Errorf("paused voice read %d samples, want 0", remaining-got)
+	}
+
+	m.resume()
+	m.mix(out)
+	if v.fade != 1 {
+		t.Errorf("fade after resuming = %f, want 1", v.fade)
+	}
+	if got := len(v.src.(*buffer).samples); got != remaining-len(out) {
+		t.Errorf("resumed voice read %d samples, want %d", remaining-got, len(out))
+	}
+}
+
+func TestFadeOut(t *testing.T) {
+	m := &mixer{}
+	m.playMusic(&buffer{make([]float32, exitFadeFrames*numOutputChannels*2)})
+	paused := &voice{src: &buffer{make([]float32, exitFadeFrames*numOutputChannels*2)}, bus: BusSFX, gain: 1, fade: 1}
+	m.play(paused)
+	m.pause()
+
+	// Paused voices should still fade out so the mixer finishes.
+	m.fadeOut(exitFadeFrames)
+	out := make([]int16, (exitFadeFrames+1)*numOutputChannels)
+	m.mix(out)
+	if m.active() {
+		t.Errorf("active() after fading out = true, want false")
+	}
+}
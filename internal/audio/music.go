@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	"github.com/btmura/blockcillin/internal/asset"
+)
+
+// Music is an enum that identifies a background music track.
+//
+//go:generate stringer -type=Music
+type Music int
+
+const (
+	MusicNone Music = iota
+	MusicMenu
+	MusicGame
+)
+
+// musicTrack describes a music asset and where it loops.
+type musicTrack struct {
+	// asset is the name of the WAV asset or the song description asset.
+	asset string
+
+	// loopStart is the frame of a WAV asset to loop back to after an intro.
+	loopStart int
+
+	// loopEnd is the frame of a WAV asset to loop at or 0 to loop at the end.
+	loopEnd int
+}
+
+// musicTracks maps Music to its track.
+var musicTracks = [...]musicTrack{
+	MusicMenu: {asset: "menu.wav"},
+	MusicGame: {asset: "game.seq"},
+}
+
+// musicStopDelay is how long to keep decoding a track after crossfading away from it.
+// It is twice the crossfade, so the track is faded out well before it stops.
+const musicStopDelay = 2 * time.Duration(crossfadeFrames) * time.Second / sampleRate
+
+// PlayMusic crossfades to the given music. It is overridden by Init.
+var PlayMusic = func(m Music) {}
+
+// openMusic returns a source for the music's track at the output sample rate or nil for MusicNone.
+// WAV tracks are streamed from their assets by a prefetcher, and the returned stop function
+// must be called once the track is no longer played to close its asset. Songs are sequenced
+// from samples in memory, so they respond to the game right away and have a nil stop function.
+func openMusic(m Music) (src source, stop func(), err error) {
+	if m == MusicNone {
+		return nil, nil, nil
+	}
+
+	t := musicTracks[m]
+	r, err := asset.Reader(t.asset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if path.Ext(t.asset) == ".seq" {
+		defer r.Close()
+		src, err := openSong(r)
+		return src, nil, err
+	}
+
+	s, err := newStream(r, t.loopStart, t.loopEnd)
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+
+	log.Printf("%s: %v", t.asset, s.w)
+	p := newPrefetcher(newResampler(s, int(s.w.sampleRate), sampleRate), r)
+	p.start()
+	return p, p.stop, nil
+}
+
+// openSong returns a sequencer for the song description with its instruments rendered.
+func openSong(r io.Reader) (source, error) {
+	s, err := decodeSong(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples [][]float32
+	for _, in := range s.instruments {
+		r, err := asset.Reader(in.asset)
+		if err != nil {
+			return nil, err
+		}
+
+		syn, err := decodeSynth(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", in.asset, err)
+		}
+		samples = append(samples, syn.render())
+	}
+
+	return newSequencer(s, samples), nil
+}
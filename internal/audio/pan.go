@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// minPositionGain is the gain of sounds from cells on the far side of the board's cylinder.
+const minPositionGain = 0.3
+
+// Position is the cell on the board that a sound comes from.
+type Position struct {
+	// X is the cell's column.
+	X int
+
+	// Y is the cell's row. Only X affects the sound since the camera follows the selector around the cylinder.
+	Y int
+}
+
+// listenerX and listenerCellCount are the selector's column and the number of cells per ring.
+// They are accessed atomically since the mixer reads them while the game changes them.
+var listenerX, listenerCellCount int32
+
+// SetListener sets the selector column and the number of cells per ring that positioned sounds
+// are panned relative to. It is safe to call at any time.
+func SetListener(x, cellCount int) {
+	atomic.StoreInt32(&listenerX, int32(x))
+	atomic.StoreInt32(&listenerCellCount, int32(cellCount))
+}
+
+// MoveListener sets the selector column that positioned sounds are panned relative to
+// and keeps the number of cells per ring. It is safe to call at any time.
+func MoveListener(x int) {
+	atomic.StoreInt32(&listenerX, int32(x))
+}
+
+// positionGains returns the left and right gains of a sound coming from the position.
+// Sounds to the sides of the selector are panned and sounds behind the cylinder are quieter.
+// Sounds without a position play at full volume in the center.
+func positionGains(pos *Position) (left, right float32) {
+	cellCount := atomic.LoadInt32(&listenerCellCount)
+	if pos == nil || cellCount == 0 {
+		return 1, 1
+	}
+
+	// The camera is centered between the two cells of the selector.
+	x := atomic.LoadInt32(&listenerX)
+	angle := 2 * math.Pi * (float64(pos.X) - float64(x) - 0.5) / float64(cellCount)
+
+	gain := minPositionGain + (1-minPositionGain)*float32(1+math.Cos(angle))/2
+	left, right = gain, gain
+	if pan := float32(math.Sin(angle)); pan > 0 {
+		left *= 1 - pan
+	} else {
+		right *= 1 + pan
+	}
+	return left, right
+}
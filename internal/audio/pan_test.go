@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPositionGains(t *testing.T) {
+	defer SetListener(0, 0)
+
+	round := func(v float32) float32 {
+		return float32(math.Floor(float64(v)*100+0.5) / 100)
+	}
+
+	for _, tt := range []struct {
+		desc      string
+		listenerX int
+		cellCount int
+		pos       *Position
+		wantLeft  float32
+		wantRight float32
+	}{
+		{
+			desc:      "no position",
+			cellCount: 4,
+			wantLeft:  1,
+			wantRight: 1,
+		},
+		{
+			desc:      "no board",
+			pos:       &Position{X: 3},
+			wantLeft:  1,
+			wantRight: 1,
+		},
+		{
+			desc:      "left cell of selector",
+			listenerX: 2,
+			cellCount: 180,
+			pos:       &Position{X: 2},
+			wantLeft:  1,
+			wantRight: 0.98,
+		},
+		{
+			desc:      "right side of cylinder",
+			listenerX: 0,
+			cellCount: 4,
+			pos:       &Position{X: 1},
+			wantLeft:  0.26,
+			wantRight: 0.9,
+		},
+		{
+			desc:      "behind the cylinder on the left",
+			listenerX: 0,
+			cellCount: 4,
+			pos:       &Position{X: 3},
+			wantLeft:  0.4,
+			wantRight: 0.12,
+		},
+	} {
+		SetListener(tt.listenerX, tt.cellCount)
+		l, r := positionGains(tt.pos)
+		if round(l) != tt.wantLeft || round(r) != tt.wantRight {
+			t.Errorf("[%s] positionGains() = %.2f, %.2f, want %.2f, %.2f", tt.desc, l, r, tt.wantLeft, tt.wantRight)
+		}
+	}
+}
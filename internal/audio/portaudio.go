@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"log"
+	"time"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// portAudioBackend is a backend that plays samples on the default output device using PortAudio.
+// It renders the samples directly in PortAudio's callback to keep the latency low.
+type portAudioBackend struct {
+	// framesPerBuffer is how many frames to render on each callback or 0 to let PortAudio choose.
+	framesPerBuffer int
+
+	// latency is the suggested output latency or 0 for the device's default low latency.
+	latency time.Duration
+
+	// stream is the open PortAudio stream.
+	stream *portaudio.Stream
+}
+
+func (b *portAudioBackend) start(render func(out []int16)) error {
+	if err := portaudio.Initialize(); err != nil {
+		return err
+	}
+
+	log.Printf("PortAudio version: %d %s", portaudio.Version(), portaudio.VersionText())
+
+	stream, err := b.open(render)
+	if err != nil {
+		portaudio.Terminate()
+		return err
+	}
+
+	if err := stream.Start(); err != nil {
+		stream.Close()
+		portaudio.Terminate()
+		return err
+	}
+
+	b.stream = stream
+	return nil
+}
+
+// open opens a stream on the default output device that calls render for its samples.
+func (b *portAudioBackend) open(render func(out []int16)) (*portaudio.Stream, error) {
+	dev, err := portaudio.DefaultOutputDevice()
+	if err != nil {
+		return nil, err
+	}
+
+	p := portaudio.LowLatencyParameters(nil, dev)
+	p.Output.Channels = numOutputChannels
+	p.SampleRate = sampleRate
+	if b.framesPerBuffer > 0 {
+		p.FramesPerBuffer = b.framesPerBuffer
+	}
+	if b.latency > 0 {
+		p.Output.Latency = b.latency
+	}
+
+	log.Printf("PortAudio device: %s latency: %v framesPerBuffer: %d", dev.Name, p.Output.Latency, p.FramesPerBuffer)
+
+	return portaudio.OpenStream(p, render)
+}
+
+func (b *portAudioBackend) stop() error {
+	// stream.Stop blocks until all samples have been played.
+	err := b.stream.Stop()
+	if cerr := b.stream.Close(); err == nil {
+		err = cerr
+	}
+	if terr := portaudio.Terminate(); err == nil {
+		err = terr
+	}
+	return err
+}
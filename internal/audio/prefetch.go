@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// prefetchSize is how many samples a prefetcher decodes ahead of the render callback.
+	// It must be a power of 2.
+	prefetchSize = 1 << 16
+
+	// prefetchBatchSize is how many samples a prefetcher decodes at a time.
+	prefetchBatchSize = 4096
+
+	// prefetchPollInterval is how often a prefetcher with a full buffer checks for room to decode more.
+	prefetchPollInterval = 10 * time.Millisecond
+)
+
+// prefetcher is a source that decodes another source on its own goroutine into a ring buffer,
+// so the render callback only copies samples that are already decoded and never reads files.
+// The ring is passed from the decoder to the callback without locks like the commandQueue.
+type prefetcher struct {
+	// src is the source to decode. Only the decoder reads it.
+	src source
+
+	// closer is closed once the decoder stops or nil if there is nothing to close.
+	closer io.Closer
+
+	// ring is the ring of decoded samples.
+	ring [prefetchSize]float32
+
+	// batch is a temporary buffer to decode samples into before copying them into the ring.
+	batch [prefetchBatchSize]float32
+
+	// head is the count of samples read. Only the callback changes it.
+	head uint32
+
+	// tail is the count of samples decoded. Only the decoder changes it.
+	tail uint32
+
+	// ended is 1 after the decoder decodes the last sample of the source.
+	ended uint32
+
+	// done is closed to stop the decoder.
+	done chan struct{}
+}
+
+// newPrefetcher returns a prefetcher of the source that closes the closer once it stops.
+// Call start to start decoding and stop once the source is no longer played.
+func newPrefetcher(src source, closer io.Closer) *prefetcher {
+	return &prefetcher{
+		src:    src,
+		closer: closer,
+		done:   make(chan struct{}),
+	}
+}
+
+// start decodes the source on a new goroutine until it ends or stop is called.
+func (p *prefetcher) start() {
+	go func() {
+		defer p.close()
+		for p.fill() {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(prefetchPollInterval):
+			}
+		}
+	}()
+}
+
+// stop stops the decoder, which closes the closer.
+func (p *prefetcher) stop() {
+	close(p.done)
+}
+
+// close closes the closer.
+func (p *prefetcher) close() {
+	if p.closer == nil {
+		return
+	}
+	if err := p.closer.Close(); err != nil {
+		log.Printf("audio: closing music: %v", err)
+	}
+}
+
+// fill decodes samples until the ring is full. It returns false once the source ends.
+// It must only be called by the decoder.
+func (p *prefetcher) fill() bool {
+	tail := atomic.LoadUint32(&p.tail)
+	for {
+		room := prefetchSize - int(tail-atomic.LoadUint32(&p.head))
+		if room == 0 {
+			return true
+		}
+		if room > prefetchBatchSize {
+			room = prefetchBatchSize
+		}
+
+		n := p.src.read(p.batch[:room])
+		for i, s := range p.batch[:n] {
+			p.ring[(tail+uint32(i))%prefetchSize] = s
+		}
+		tail += uint32(n)
+		atomic.StoreUint32(&p.tail, tail)
+
+		if n < room {
+			atomic.StoreUint32(&p.ended, 1)
+			return false
+		}
+	}
+}
+
+// read implements source. It plays silence instead of waiting when the decoder falls behind
+// and only returns less than len(buf) once every sample of the source is read.
+func (p *prefetcher) read(buf []float32) int {
+	ended := atomic.LoadUint32(&p.ended) == 1
+	head := atomic.LoadUint32(&p.head)
+	avail := int(atomic.LoadUint32(&p.tail) - head)
+
+	n := len(buf)
+	if n > avail {
+		n = avail
+	}
+	for i := range buf[:n] {
+		buf[i] = p.ring[(head+uint32(i))%prefetchSize]
+	}
+	atomic.StoreUint32(&p.head, head+uint32(n))
+
+	if ended {
+		return n
+	}
+	for i := range buf[n:] {
+		buf[n+i] = 0
+	}
+	return len(buf)
+}
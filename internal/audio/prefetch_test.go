@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testCloser signals on its channel when it is closed.
+type testCloser struct {
+	closed chan bool
+}
+
+func (c *testCloser) Close() error {
+	c.closed <- true
+	return nil
+}
+
+func TestPrefetcherRead(t *testing.T) {
+	p := newPrefetcher(&buffer{samples: []float32{1, 2, 3, 4, 5, 6}}, nil)
+
+	// The decoder falls behind, so the callback plays silence instead of waiting.
+	buf := make([]float32, 4)
+	if n := p.read(buf); n != len(buf) {
+		t.Errorf("read() = %d, want %d before decoding", n, len(buf))
+	}
+	if want := []float32{0, 0, 0, 0}; !reflect.DeepEqual(buf, want) {
+		t.Errorf("read() -> %v, want %v before decoding", buf, want)
+	}
+
+	if p.fill() {
+		t.Errorf("fill() = true, want false once the source ends")
+	}
+
+	if n := p.read(buf); n != len(buf) {
+		t.Errorf("read() = %d, want %d", n, len(buf))
+	}
+	if want := []float32{1, 2, 3, 4}; !reflect.DeepEqual(buf, want) {
+		t.Errorf("read() -> %v, want %v", buf, want)
+	}
+
+	// The source ends once every decoded sample is read.
+	if n := p.read(buf); n != 2 {
+		t.Errorf("read() = %d, want 2 at the end", n)
+	}
+	if want := []float32{5, 6}; !reflect.DeepEqual(buf[:2], want) {
+		t.Errorf("read() -> %v, want %v at the end", buf[:2], want)
+	}
+}
+
+func TestPrefetcherFillWraps(t *testing.T) {
+	samples := make([]float32, prefetchSize*3)
+	for i := range samples {
+		samples[i] = float32(i)
+	}
+	p := newPrefetcher(&buffer{samples: samples}, nil)
+
+	// Fill the ring, read part of it, and fill the room left past the end of the ring.
+	buf := make([]float32, prefetchSize/2+prefetchBatchSize+1)
+	var got []float32
+	for i := 0; i < 4; i++ {
+		if !p.fill() {
+			t.Fatalf("fill() = false, want true before the source ends")
+		}
+		n := p.read(buf)
+		got = append(got, buf[:n]...)
+	}
+
+	if !reflect.DeepEqual(got, samples[:len(got)]) {
+		t.Errorf("read() samples differ from the source's samples")
+	}
+}
+
+func TestPrefetcherStop(t *testing.T) {
+	c := &testCloser{closed: make(chan bool, 1)}
+	p := newPrefetcher(&buffer{samples: make([]float32, prefetchSize*2)}, c)
+	p.start()
+	p.stop()
+
+	select {
+	case <-c.closed:
+	case <-time.After(time.Second):
+		t.Errorf("closer not closed after stop")
+	}
+}
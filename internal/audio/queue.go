@@ -0,0 +1,90 @@
+package audio
+
+import "sync/atomic"
+
+// commandQueueSize is how many commands can wait for the next render. It must be a power of 2.
+const commandQueueSize = 256
+
+// commandType is the type of a command sent to the render callback.
+type commandType int
+
+const (
+	// commandPlaySound starts playing a sound.
+	commandPlaySound commandType = iota
+
+	// commandPlayMusic crossfades to another music source.
+	commandPlayMusic
+
+	// commandPause pauses the playing voices.
+	commandPause
+
+	// commandResume resumes the paused voices.
+	commandResume
+
+	// commandFadeOut fades out everything before the game exits.
+	commandFadeOut
+
+	// commandStartCapture starts teeing the mixed samples to a capture.
+	commandStartCapture
+
+	// commandStopCapture stops the current capture and finishes its file.
+	commandStopCapture
+
+	// commandSetSounds replaces the sounds' samples after they are reloaded.
+	commandSetSounds
+)
+
+// command is a request from the game to the render callback.
+type command struct {
+	typ commandType
+
+	// sound is the sound to play for commandPlaySound.
+	sound soundRequest
+
+	// music is the source to crossfade to for commandPlayMusic or nil to stop the music.
+	music source
+
+	// capture is the capture to start for commandStartCapture.
+	capture *capture
+
+	// sounds maps Sound to its new samples for commandSetSounds.
+	sounds [][]float32
+}
+
+// commandQueue is a lock-free queue that passes commands from a single producer
+// to a single consumer without ever blocking either of them.
+type commandQueue struct {
+	// commands is the ring of queued commands.
+	commands [commandQueueSize]command
+
+	// head is the count of commands popped. Only the consumer changes it.
+	head uint32
+
+	// tail is the count of commands pushed. Only the producer changes it.
+	tail uint32
+}
+
+// push adds the command to the queue. It returns false if the queue is full.
+// It must only be called by the producer.
+func (q *commandQueue) push(c command) bool {
+	tail := atomic.LoadUint32(&q.tail)
+	if tail-atomic.LoadUint32(&q.head) == commandQueueSize {
+		return false
+	}
+	q.commands[tail%commandQueueSize] = c
+	atomic.StoreUint32(&q.tail, tail+1)
+	return true
+}
+
+// pop removes the oldest command from the queue. It returns false if the queue is empty.
+// It must only be called by the consumer.
+func (q *commandQueue) pop() (command, bool) {
+	head := atomic.LoadUint32(&q.head)
+	if head == atomic.LoadUint32(&q.tail) {
+		return command{}, false
+	}
+	c := q.commands[head%commandQueueSize]
+	q.commands[head%commandQueueSize] = command{} // Release the source for the garbage collector.
+	atomic.StoreUint32(&q.head, head+1)
+	return c, true
+}
@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCommandQueue(t *testing.T) {
+	q := &commandQueue{}
+
+	if _, ok := q.pop(); ok {
+		t.Errorf("pop() on empty queue returned true, want false")
+	}
+
+	for i := 0; i < commandQueueSize; i++ {
+		if !q.push(command{sound: soundRequest{sound: Sound(i)}}) {
+			t.Fatalf("push(%d) returned false, want true", i)
+		}
+	}
+	if q.push(command{}) {
+		t.Errorf("push() on full queue returned true, want false")
+	}
+
+	for i := 0; i < commandQueueSize; i++ {
+		c, ok := q.pop()
+		if !ok || c.sound.sound != Sound(i) {
+			t.Fatalf("pop() = %v, %t, want sound %d, true", c.sound.sound, ok, i)
+		}
+	}
+}
+
+func TestCommandQueueConcurrent(t *testing.T) {
+	const n = 10000
+	q := &commandQueue{}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; {
+			if q.push(command{sound: soundRequest{sound: Sound(i)}}) {
+				i++
+			}
+		}
+	}()
+
+	for i := 0; i < n; {
+		c, ok := q.pop()
+		if !ok {
+			continue
+		}
+		if c.sound.sound != Sound(i) {
+			t.Fatalf("pop() = %d, want %d", c.sound.sound, i)
+		}
+		i++
+	}
+	wg.Wait()
+}
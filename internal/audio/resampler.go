@@ -0,0 +1,133 @@
+package audio
+
+// resamplerBatchFrames is how many frames a resampler reads from its source at a time.
+const resamplerBatchFrames = 1024
+
+// resampler is a source that converts another source's samples to a different sample rate
+// by linearly interpolating between its frames.
+type resampler struct {
+	// src is the source with the samples at the original rate.
+	src source
+
+	// step is how many source frames to advance for each output frame.
+	step float64
+
+	// pos is the position between the prev and next frames from 0 to 1.
+	pos float64
+
+	// prev and next are the source frames surrounding the current position.
+	prev, next [numOutputChannels]float32
+
+	// primed is whether prev and next have been read from the source yet.
+	primed bool
+
+	// in is the batch of source samples not yet moved into prev and next.
+	in []float32
+
+	// inBuf is the buffer that in is sliced from.
+	inBuf []float32
+
+	// srcDone is whether the source has returned its last samples.
+	srcDone bool
+
+	// ended is whether next is the last frame of the source held in place.
+	ended bool
+}
+
+// newResampler returns a source that plays src recorded at fromRate at toRate.
+// It returns src itself if the rates are the same.
+func newResampler(src source, fromRate, toRate int) source {
+	return newPitchShifter(src, float64(fromRate)/float64(toRate))
+}
+
+// newPitchShifter returns a source that plays src faster and higher by the pitch ratio or
+// slower and lower if the ratio is less than 1. It returns src itself if the ratio is 1.
+func newPitchShifter(src source, pitch float64) source {
+	if pitch == 1 {
+		return src
+	}
+	return &resampler{
+		src:   src,
+		step:  pitch,
+		inBuf: make([]float32, resamplerBatchFrames*numOutputChannels),
+	}
+}
+
+// reset plays src from the start by the pitch ratio like newPitchShifter, reusing the input buffer
+// so that it does not allocate.
+func (r *resampler) reset(src source, pitch float64) {
+	*r = resampler{
+		src:   src,
+		step:  pitch,
+		inBuf: r.inBuf,
+	}
+	if r.inBuf == nil {
+		r.inBuf = make([]float32, resamplerBatchFrames*numOutputChannels)
+	}
+}
+
+// read implements source.
+func (r *resampler) read(buf []float32) int {
+	if !r.primed {
+		r.primed = true
+		if !r.nextFrame(&r.prev) {
+			return 0
+		}
+		if !r.nextFrame(&r.next) {
+			r.next, r.ended = r.prev, true
+		}
+	}
+
+	n := 0
+	for ; n+1 < len(buf); n += numOutputChannels {
+		for r.pos >= 1 && !r.ended {
+			r.prev = r.next
+			r.pos--
+			if !r.nextFrame(&r.next) {
+				// Hold the last frame so it still plays if the position lands exactly on it.
+				r.next, r.ended = r.prev, true
+			}
+		}
+		if r.ended && r.pos > 0 {
+			return n
+		}
+
+		p := float32(r.pos)
+		for c := 0; c < numOutputChannels; c++ {
+			buf[n+c] = r.prev[c] + (r.next[c]-r.prev[c])*p
+		}
+		r.pos += r.step
+	}
+	return n
+}
+
+// nextFrame reads the next source frame into f and returns false if there are no more.
+func (r *resampler) nextFrame(f *[numOutputChannels]float32) bool {
+	if len(r.in) < numOutputChannels {
+		if r.srcDone {
+			return false
+		}
+		n := r.src.read(r.inBuf)
+		if n < len(r.inBuf) {
+			r.srcDone = true
+		}
+		r.in = r.inBuf[:n-n%numOutputChannels]
+		if len(r.in) == 0 {
+			return false
+		}
+	}
+	copy(f[:], r.in)
+	r.in = r.in[numOutputChannels:]
+	return true
+}
+
+// resample returns the interleaved stereo samples recorded at fromRate converted to toRate.
+func resample(samples []float32, fromRate, toRate int) []float32 {
+	if fromRate == toRate {
+		return samples
+	}
+	numFrames := len(samples) / numOutputChannels
+	buf := make([]float32, (numFrames*toRate/fromRate+1)*numOutputChannels)
+	n := newResampler(&buffer{samples}, fromRate, toRate).read(buf)
+	return buf[:n]
+}
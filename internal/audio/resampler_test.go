@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestResample(t *testing.T) {
+	for _, tt := range []struct {
+		desc     string
+		samples  []float32
+		fromRate int
+		toRate   int
+		want     []float32
+	}{
+		{
+			desc:     "same rate",
+			samples:  []float32{0.5, -0.5, 0.25, -0.25},
+			fromRate: 44100,
+			toRate:   44100,
+			want:     []float32{0.5, -0.5, 0.25, -0.25},
+		},
+		{
+			desc:     "upsample interpolates",
+			samples:  []float32{0, 0, 0.5, -0.5, 1, -1},
+			fromRate: 22050,
+			toRate:   44100,
+			want:     []float32{0, 0, 0.25, -0.25, 0.5, -0.5, 0.75, -0.75, 1, -1},
+		},
+		{
+			desc:     "downsample skips",
+			samples:  []float32{0, 0, 0.25, 0.25, 0.5, 0.5, 0.75, 0.75, 1, 1},
+			fromRate: 88200,
+			toRate:   44100,
+			want:     []float32{0, 0, 0.5, 0.5, 1, 1},
+		},
+		{
+			desc:     "non integer ratio",
+			samples:  []float32{0, 0, 0.3, 0.3, 0.6, 0.6, 0.9, 0.9},
+			fromRate: 48000,
+			toRate:   32000,
+			want:     []float32{0, 0, 0.45, 0.45, 0.9, 0.9},
+		},
+	} {
+		got := resample(tt.samples, tt.fromRate, tt.toRate)
+		for i, v := range got {
+			got[i] = float32(math.Floor(float64(v)*1e4+0.5) / 1e4)
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("[%s] resample() differs:\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestPitchShifter(t *testing.T) {
+	src := &buffer{[]float32{0, 0, 0.25, 0.25, 0.5, 0.5, 0.75, 0.75, 1, 1}}
+	buf := make([]float32, 10)
+	n := newPitchShifter(src, 2).read(buf)
+
+	want := []float32{0, 0, 0.5, 0.5, 1, 1}
+	if diff := pretty.Compare(buf[:n], want); diff != "" {
+		t.Errorf("read() differs:\n%s", diff)
+	}
+}
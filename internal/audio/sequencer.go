@@ -0,0 +1,483 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// stepsPerBeat is how many steps of a track make up a beat of the tempo.
+	stepsPerBeat = 4
+
+	// stepsPerBar is how many steps make up a bar. Intensity changes only take effect on new bars.
+	stepsPerBar = 16
+
+	// maxSequencerVoices is the most notes the sequencer plays at once.
+	// The oldest notes quickly fade out to make room for new ones.
+	maxSequencerVoices = 32
+
+	// sequencerVoiceCount is how many voices the sequencer has to play notes with.
+	// It has room for maxSequencerVoices playing notes and as many notes fading out.
+	sequencerVoiceCount = 2 * maxSequencerVoices
+
+	// sequencerBatchFrames is how many frames the sequencer mixes from each note at a time.
+	sequencerBatchFrames = 1024
+)
+
+// musicIntensity, musicTense, and musicStingers control the sequenced music.
+// They are accessed atomically since the sequencer reads them while the game changes them.
+var musicIntensity, musicTense, musicStingers int32
+
+// SetMusicIntensity sets how many layers of the sequenced music play starting from 0.
+// It takes effect on the next bar. It is safe to call at any time.
+func SetMusicIntensity(level int) {
+	atomic.StoreInt32(&musicIntensity, int32(level))
+}
+
+// SetMusicTense sets whether the sequenced music plays its tense variation.
+// It takes effect on the next bar. It is safe to call at any time.
+func SetMusicTense(tense bool) {
+	var v int32
+	if tense {
+		v = 1
+	}
+	atomic.StoreInt32(&musicTense, v)
+}
+
+// PlayStinger plays the sequenced music's stinger on the next beat. It is safe to call at any time.
+func PlayStinger() {
+	atomic.AddInt32(&musicStingers, 1)
+}
+
+// song is a piece of music made of tracks of notes played by synthesized instruments.
+//
+// Song descriptions are text with one statement per line and # comments:
+//
+//	tempo 132                          # beats per minute with 4 steps per beat
+//	instrument bass music_bass.sfx C2  # name, synth asset, and the note the synth plays
+//	track bass 1                       # starts a track that plays from intensity level 1
+//	tense bass                         # starts a track that plays only in the tense variation
+//	stinger lead                       # starts the track played once on big chains
+//	notes C2 . . . G2 . A#1 .          # adds steps with notes or . rests to the current track
+//
+// Tracks loop independently over their steps. Tracks from level 0 always play while tracks
+// from higher levels are replaced by the tense tracks in the tense variation.
+type song struct {
+	tempo       float64
+	instruments []*songInstrument
+	tracks      []*songTrack
+	stinger     *songTrack
+}
+
+// songInstrument is a synth that plays the notes of tracks.
+type songInstrument struct {
+	// name is the name that tracks use to refer to the instrument.
+	name string
+
+	// asset is the name of the synth description asset.
+	asset string
+
+	// root is the MIDI note that the synth plays without any pitch shift.
+	root int
+}
+
+// songTrack is a looping sequence of notes played by an instrument.
+type songTrack struct {
+	// instrument is the index of the track's instrument.
+	instrument int
+
+	// level is the intensity level from which the track plays.
+	level int
+
+	// tense is whether the track only plays in the tense variation.
+	tense bool
+
+	// notes are the MIDI notes of each step or -1 for rests.
+	notes []int
+}
+
+// decodeSong decodes a song description.
+func decodeSong(r io.Reader) (*song, error) {
+	s := &song{tempo: 120}
+	var track *songTrack
+
+	instrumentIndex := func(name string) (int, error) {
+		for i, in := range s.instruments {
+			if in.name == name {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown instrument: %s", name)
+	}
+
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		fields := strings.Fields(stripComment(sc.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch args := fields[1:]; fields[0] {
+		case "tempo":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("line %d: tempo should have one value", lineNum)
+			}
+			if s.tempo, err = strconv.ParseFloat(args[0], 64); err != nil || s.tempo < 30 || s.tempo > 300 {
+				return nil, fmt.Errorf("line %d: invalid tempo: %s", lineNum, args[0])
+			}
+
+		case "instrument":
+			if len(args) != 3 {
+				return nil, fmt.Errorf("line %d: instrument should have a name, asset, and note", lineNum)
+			}
+			in := &songInstrument{name: args[0], asset: args[1]}
+			if in.root, err = parseNote(args[2]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			s.instruments = append(s.instruments, in)
+
+		case "track", "tense", "stinger":
+			track = &songTrack{}
+			if len(args) == 0 {
+				return nil, fmt.Errorf("line %d: %s should have an instrument", lineNum, fields[0])
+			}
+			if track.instrument, err = instrumentIndex(args[0]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+
+			switch fields[0] {
+			case "track":
+				if len(args) != 2 {
+					return nil, fmt.Errorf("line %d: track should have an instrument and level", lineNum)
+				}
+				if track.level, err = strconv.Atoi(args[1]); err != nil || track.level < 0 {
+					return nil, fmt.Errorf("line %d: invalid level: %s", lineNum, args[1])
+				}
+				s.tracks = append(s.tracks, track)
+
+			case "tense":
+				track.tense = true
+				s.tracks = append(s.tracks, track)
+
+			case "stinger":
+				if s.stinger != nil {
+					return nil, fmt.Errorf("line %d: duplicate stinger", lineNum)
+				}
+				s.stinger = track
+			}
+
+		case "notes":
+			if track == nil {
+				return nil, fmt.Errorf("line %d: notes before first track", lineNum)
+			}
+			for _, a := range args {
+				n := -1
+				if a != "." {
+					if n, err = parseNote(a); err != nil {
+						return nil, fmt.Errorf("line %d: %v", lineNum, err)
+					}
+				}
+				track.notes = append(track.notes, n)
+			}
+
+		default:
+			return nil, fmt.Errorf("line %d: unknown statement: %s", lineNum, fields[0])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(s.tracks) == 0 {
+		return nil, fmt.Errorf("no tracks")
+	}
+	for i, t := range append(s.tracks, s.stinger) {
+		if t != nil && len(t.notes) == 0 {
+			return nil, fmt.Errorf("track %d has no notes", i+1)
+		}
+	}
+	return s, nil
+}
+
+// noteSemitones maps note letters to semitones above C.
+var noteSemitones = map[byte]int{
+	'C': 0,
+	'D': 2,
+	'E': 4,
+	'F': 5,
+	'G': 7,
+	'A': 9,
+	'B': 11,
+}
+
+// parseNote parses a note like C4, F#2, or Bb3 into a MIDI note where C4 is 60.
+func parseNote(s string) (int, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid note: %s", s)
+	}
+
+	n, ok := noteSemitones[s[0]]
+	if !ok {
+		return 0, fmt.Errorf("invalid note: %s", s)
+	}
+
+	octave := s[1:]
+	switch octave[0] {
+	case '#':
+		n++
+		octave = octave[1:]
+	case 'b':
+		n--
+		octave = octave[1:]
+	}
+
+	o, err := strconv.Atoi(octave)
+	if err != nil || o < 0 || o > 9 {
+		return 0, fmt.Errorf("invalid note: %s", s)
+	}
+	return 12*(o+1) + n, nil
+}
+
+// sequencer is a source that plays a song forever.
+type sequencer struct {
+	// song is the song to play.
+	song *song
+
+	// samples maps instrument index to the samples of its rendered synth.
+	samples [][]float32
+
+	// framesPerStep is how many frames each step lasts.
+	framesPerStep int
+
+	// untilStep is how many frames are left before the next step.
+	untilStep int
+
+	// step is the next step to play counting from the start of the song.
+	step int
+
+	// level is the intensity level for the current bar.
+	level int
+
+	// tense is whether the current bar plays the tense variation.
+	tense bool
+
+	// stingers is how many stingers have been requested as of the last step.
+	stingers int32
+
+	// stingerStep is the next step of the playing stinger or -1 if it is not playing.
+	stingerStep int
+
+	// voices are the voices that notes are played with. They are reused,
+	// so that playing notes in the render callback never allocates.
+	voices [sequencerVoiceCount]sequencerVoice
+
+	// notes is how many notes have been played, which orders the voices from oldest to newest.
+	notes int
+
+	// voiceBuf is a temporary buffer to read each voice's samples into.
+	voiceBuf []float32
+}
+
+// sequencerVoice is a reusable voice of a note with its samples and the pitch shifter that plays them.
+type sequencerVoice struct {
+	// src is the samples or the pitch shifter.
+	src source
+
+	// samples is the source of the instrument's samples.
+	samples buffer
+
+	// shifter plays the samples at the note's pitch unless it is the instrument's root note.
+	shifter resampler
+
+	// playing is set while the voice has samples left to play.
+	playing bool
+
+	// note is the number of the note from the start of the song, so older notes have lower numbers.
+	note int
+
+	// stolen is set when the voice is fading out to make room for a newer note.
+	stolen bool
+
+	// fade is the voice's gain from 1, which falls to 0 once it is stolen.
+	fade float32
+}
+
+// newSequencer returns a sequencer that plays the song with the rendered instrument samples.
+func newSequencer(s *song, samples [][]float32) *sequencer {
+	seq := &sequencer{
+		song:          s,
+		samples:       samples,
+		framesPerStep: int(sampleRate * 60 / s.tempo / stepsPerBeat),
+		stingers:      atomic.LoadInt32(&musicStingers),
+		stingerStep:   -1,
+		voiceBuf:      make([]float32, sequencerBatchFrames*numOutputChannels),
+	}
+	for i := range seq.voices {
+		seq.voices[i].shifter.reset(nil, 1)
+	}
+	return seq
+}
+
+// read implements source. It always fills the buffer since songs loop forever.
+func (s *sequencer) read(buf []float32) int {
+	for i := range buf {
+		buf[i] = 0
+	}
+
+	n := 0
+	for n+1 < len(buf) {
+		if s.untilStep == 0 {
+			s.playStep()
+			s.untilStep = s.framesPerStep
+		}
+
+		numFrames := (len(buf) - n) / numOutputChannels
+		if numFrames > s.untilStep {
+			numFrames = s.untilStep
+		}
+
+		s.mixVoices(buf[n : n+numFrames*numOutputChannels])
+		n += numFrames * numOutputChannels
+		s.untilStep -= numFrames
+	}
+	return n
+}
+
+// playStep starts the notes of the next step.
+func (s *sequencer) playStep() {
+	// Only change the layers at the start of bars so the music stays in time.
+	if s.step%stepsPerBar == 0 {
+		s.level = int(atomic.LoadInt32(&musicIntensity))
+		s.tense = atomic.LoadInt32(&musicTense) == 1
+	}
+
+	// Start stingers on beats, and only one at a time.
+	if stingers := atomic.LoadInt32(&musicStingers); stingers != s.stingers && s.step%stepsPerBeat == 0 {
+		s.stingers = stingers
+		if s.song.stinger != nil && s.stingerStep < 0 {
+			s.stingerStep = 0
+		}
+	}
+
+	for _, t := range s.song.tracks {
+		if s.plays(t) {
+			s.playNote(t, t.notes[s.step%len(t.notes)])
+		}
+	}
+
+	if t := s.song.stinger; s.stingerStep >= 0 {
+		s.playNote(t, t.notes[s.stingerStep])
+		if s.stingerStep++; s.stingerStep == len(t.notes) {
+			s.stingerStep = -1
+		}
+	}
+
+	s.step++
+}
+
+// plays returns whether the track plays in the current bar.
+func (s *sequencer) plays(t *songTrack) bool {
+	switch {
+	case t.tense:
+		return s.tense
+	case t.level == 0:
+		return true
+	default:
+		return !s.tense && t.level <= s.level
+	}
+}
+
+// playNote starts playing the note with the track's instrument. It does nothing for rests.
+func (s *sequencer) playNote(t *songTrack, note int) {
+	if note < 0 {
+		return
+	}
+
+	in := s.song.instruments[t.instrument]
+	pitch := math.Pow(2, float64(note-in.root)/12)
+
+	// Fade out the oldest note if too many are playing, and take any voice that is free.
+	var free, oldest *sequencerVoice
+	numPlaying := 0
+	for i := range s.voices {
+		v := &s.voices[i]
+		switch {
+		case !v.playing:
+			if free == nil {
+				free = v
+			}
+		case !v.stolen:
+			numPlaying++
+			if oldest == nil || v.note < oldest.note {
+				oldest = v
+			}
+		}
+	}
+	if numPlaying >= maxSequencerVoices {
+		oldest.stolen = true
+	}
+	if free == nil {
+		return
+	}
+
+	free.samples = buffer{s.samples[t.instrument]}
+	free.src = &free.samples
+	if pitch != 1 {
+		free.shifter.reset(&free.samples, pitch)
+		free.src = &free.shifter
+	}
+	free.playing = true
+	free.note = s.notes
+	free.stolen = false
+	free.fade = 1
+	s.notes++
+}
+
+// mixVoices adds the next samples of the playing notes to buf.
+func (s *sequencer) mixVoices(buf []float32) {
+	for len(buf) > 0 {
+		n := len(buf)
+		if n > len(s.voiceBuf) {
+			n = len(s.voiceBuf)
+		}
+		for i := range s.voices {
+			if v := &s.voices[i]; v.playing {
+				s.mixVoice(buf[:n], v)
+			}
+		}
+		buf = buf[n:]
+	}
+}
+
+// mixVoice adds the voice's next samples to buf. Stolen voices fade out over stealFadeFrames.
+func (s *sequencer) mixVoice(buf []float32, v *sequencerVoice) {
+	vbuf := s.voiceBuf[:len(buf)]
+	n := v.src.read(vbuf)
+
+	// Stop notes that have finished.
+	if n < len(vbuf) {
+		v.playing = false
+	}
+
+	if !v.stolen {
+		for j := 0; j < n; j++ {
+			buf[j] += vbuf[j]
+		}
+		return
+	}
+
+	for j := 0; j+1 < n; j += numOutputChannels {
+		if v.fade -= 1.0 / stealFadeFrames; v.fade <= 0 {
+			v.playing = false
+			return
+		}
+		buf[j] += vbuf[j] * v.fade
+		buf[j+1] += vbuf[j+1] * v.fade
+	}
+}
@@ -0,0 +1,271 @@
+package audio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParseNote(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "C4", want: 60},
+		{input: "A4", want: 69},
+		{input: "C#4", want: 61},
+		{input: "Bb3", want: 58},
+		{input: "C-1", wantErr: true},
+		{input: "H4", wantErr: true},
+		{input: "C", wantErr: true},
+		{input: "C#", wantErr: true},
+	} {
+		got, err := parseNote(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want && !tt.wantErr {
+			t.Errorf("parseNote(%q) = (%d, %v), want (%d, error: %t)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDecodeSong(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    *song
+		wantErr bool
+	}{
+		{
+			desc: "tracks",
+			input: `
+tempo 140 # Comment.
+instrument bass bass.sfx C2
+instrument lead lead.sfx C4
+
+track bass 0
+notes C2 . G2 . # Comment after notes.
+notes A#1
+
+track lead 2
+notes C4
+
+tense lead
+notes . C5
+
+stinger lead
+notes C4 E4 G4
+`,
+			want: &song{
+				tempo: 140,
+				instruments: []*songInstrument{
+					{name: "bass", asset: "bass.sfx", root: 36},
+					{name: "lead", asset: "lead.sfx", root: 60},
+				},
+				tracks: []*songTrack{
+					{instrument: 0, notes: []int{36, -1, 43, -1, 34}},
+					{instrument: 1, level: 2, notes: []int{60}},
+					{instrument: 1, tense: true, notes: []int{-1, 72}},
+				},
+				stinger: &songTrack{instrument: 1, notes: []int{60, 64, 67}},
+			},
+		},
+		{
+			desc:    "no tracks",
+			input:   "tempo 120",
+			wantErr: true,
+		},
+		{
+			desc:    "unknown instrument",
+			input:   "track bass 0\nnotes C2",
+			wantErr: true,
+		},
+		{
+			desc:    "notes before track",
+			input:   "instrument bass bass.sfx C2\nnotes C2",
+			wantErr: true,
+		},
+		{
+			desc:    "track without notes",
+			input:   "instrument bass bass.sfx C2\ntrack bass 0",
+			wantErr: true,
+		},
+		{
+			desc:    "bad note",
+			input:   "instrument bass bass.sfx C2\ntrack bass 0\nnotes X2",
+			wantErr: true,
+		},
+		{
+			desc:    "bad tempo",
+			input:   "tempo fast\ninstrument bass bass.sfx C2\ntrack bass 0\nnotes C2",
+			wantErr: true,
+		},
+		{
+			desc:    "unknown statement",
+			input:   "volume 11",
+			wantErr: true,
+		},
+	} {
+		got, err := decodeSong(strings.NewReader(tt.input))
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("[%s] decodeSong: got error %v, want nil", tt.desc, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("[%s] decodeSong: got nil error, want error", tt.desc)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("[%s] decodeSong differs:\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestSequencerRead(t *testing.T) {
+	defer func() {
+		SetMusicIntensity(0)
+		SetMusicTense(false)
+	}()
+
+	// Each instrument plays a single frame so each step's notes show up as one frame of the output.
+	s := &song{
+		tempo: 120,
+		instruments: []*songInstrument{
+			{root: 60},
+			{root: 60},
+			{root: 60},
+		},
+		tracks: []*songTrack{
+			{instrument: 0, notes: []int{60, -1}},
+			{instrument: 1, level: 1, notes: []int{60}},
+			{instrument: 2, tense: true, notes: []int{60}},
+		},
+	}
+	samples := [][]float32{
+		{0.1, 0.1},
+		{0.2, 0.2},
+		{0.4, 0.4},
+	}
+
+	// readBar reads a bar of steps and returns the first frame of each step.
+	readBar := func(seq *sequencer) []float32 {
+		buf := make([]float32, stepsPerBar*seq.framesPerStep*numOutputChannels)
+		seq.read(buf)
+		var got []float32
+		for i := 0; i < len(buf); i += seq.framesPerStep * numOutputChannels {
+			got = append(got, buf[i])
+		}
+		return got
+	}
+
+	round := func(v []float32) []float32 {
+		for i := range v {
+			v[i] = float32(int(v[i]*10+0.5)) / 10
+		}
+		return v
+	}
+
+	bar := func(a, b float32) []float32 {
+		var v []float32
+		for i := 0; i < stepsPerBar/2; i++ {
+			v = append(v, a, b)
+		}
+		return v
+	}
+
+	seq := newSequencer(s, samples)
+	seq.framesPerStep = 2
+
+	for _, tt := range []struct {
+		desc      string
+		intensity int
+		tense     bool
+		want      []float32
+	}{
+		{
+			desc: "base level",
+			want: bar(0.1, 0),
+		},
+		{
+			desc:      "higher level adds layers",
+			intensity: 1,
+			want:      bar(0.3, 0.2),
+		},
+		{
+			desc:      "tense replaces higher levels",
+			intensity: 1,
+			tense:     true,
+			want:      bar(0.5, 0.4),
+		},
+	} {
+		SetMusicIntensity(tt.intensity)
+		SetMusicTense(tt.tense)
+		if got := round(readBar(seq)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] read() steps = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestSequencerStinger(t *testing.T) {
+	s := &song{
+		tempo:       120,
+		instruments: []*songInstrument{{root: 60}},
+		tracks:      []*songTrack{{instrument: 0, notes: []int{-1}}},
+		stinger:     &songTrack{instrument: 0, notes: []int{60, 60}},
+	}
+	seq := newSequencer(s, [][]float32{{1, 1}})
+	seq.framesPerStep = 1
+
+	buf := make([]float32, 2*numOutputChannels)
+	seq.read(buf)
+
+	PlayStinger()
+
+	// The stinger waits for the next beat.
+	seq.read(buf)
+	if want := make([]float32, len(buf)); !reflect.DeepEqual(buf, want) {
+		t.Errorf("read() before beat = %v, want %v", buf, want)
+	}
+
+	buf = make([]float32, stepsPerBeat*numOutputChannels)
+	seq.read(buf)
+	if want := []float32{1, 1, 1, 1, 0, 0, 0, 0}; !reflect.DeepEqual(buf, want) {
+		t.Errorf("read() on beat = %v, want %v", buf, want)
+	}
+}
+
+func TestSequencerVoices(t *testing.T) {
+	// One more track than there are voices plays a long note on the first step.
+	s := &song{tempo: 120, instruments: []*songInstrument{{root: 60}}}
+	for i := 0; i <= maxSequencerVoices; i++ {
+		s.tracks = append(s.tracks, &songTrack{instrument: 0, notes: []int{60, -1, -1, -1}})
+	}
+	samples := make([]float32, 4*stealFadeFrames*numOutputChannels)
+	for i := range samples {
+		samples[i] = 1
+	}
+	seq := newSequencer(s, [][]float32{samples})
+	seq.framesPerStep = 2 * stealFadeFrames
+
+	buf := make([]float32, 2*stealFadeFrames*numOutputChannels)
+	seq.read(buf)
+
+	// The oldest note fades out instead of being cut off.
+	if got := buf[0]; got <= maxSequencerVoices || got >= maxSequencerVoices+1 {
+		t.Errorf("first frame = %v, want between %d and %d while the oldest note fades out", got, maxSequencerVoices, maxSequencerVoices+1)
+	}
+	if got := buf[len(buf)-1]; got != maxSequencerVoices {
+		t.Errorf("last frame = %v, want %d after the oldest note faded out", got, maxSequencerVoices)
+	}
+
+	// Playing notes reuses the voices and their pitch shifters instead of allocating.
+	s.tracks[0].notes[0] = 67
+	if n := testing.AllocsPerRun(10, func() {
+		seq.read(buf)
+	}); n != 0 {
+		t.Errorf("allocations per read = %v, want 0", n)
+	}
+}
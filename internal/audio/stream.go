@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+)
+
+// streamBatchFrames is how many frames a stream decodes at a time.
+const streamBatchFrames = 1024
+
+// stream is a source that decodes a long WAV track in small batches and loops it.
+type stream struct {
+	// r is the reader with the WAV data.
+	r io.ReadSeeker
+
+	// w is the WAV header without any data.
+	w *wav
+
+	// dataOffset is the offset of the WAV data within the reader.
+	dataOffset int64
+
+	// loopStart is the frame to go back to when reaching loopEnd.
+	loopStart int
+
+	// loopEnd is the frame after the last frame to play before looping.
+	loopEnd int
+
+	// frame is the next frame to decode.
+	frame int
+
+	// data is a temporary buffer to read undecoded data into.
+	data []byte
+}
+
+// newStream returns a stream that loops the WAV data between loopStart and loopEnd.
+// A loopEnd of 0 loops at the end of the data.
+func newStream(r io.ReadSeeker, loopStart, loopEnd int) (*stream, error) {
+	w, err := decodeWAVHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dataOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	numFrames := w.numFrames()
+	if loopEnd == 0 || loopEnd > numFrames {
+		loopEnd = numFrames
+	}
+	if loopStart < 0 || loopStart >= loopEnd {
+		return nil, fmt.Errorf("invalid loop points: %d %d", loopStart, loopEnd)
+	}
+
+	return &stream{
+		r:          r,
+		w:          w,
+		dataOffset: dataOffset,
+		loopStart:  loopStart,
+		loopEnd:    loopEnd,
+		data:       make([]byte, streamBatchFrames*int(w.blockAlign)),
+	}, nil
+}
+
+// read implements source. It always fills the buffer unless the reader fails.
+func (s *stream) read(buf []float32) int {
+	n := 0
+	for n+1 < len(buf) {
+		// Go back to the loop start once the loop end is reached.
+		if s.frame >= s.loopEnd {
+			if err := s.seek(s.loopStart); err != nil {
+				return n
+			}
+		}
+
+		numFrames := (len(buf) - n) / numOutputChannels
+		if numFrames > streamBatchFrames {
+			numFrames = streamBatchFrames
+		}
+		if numFrames > s.loopEnd-s.frame {
+			numFrames = s.loopEnd - s.frame
+		}
+
+		data := s.data[:numFrames*int(s.w.blockAlign)]
+		if _, err := io.ReadFull(s.r, data); err != nil {
+			return n
+		}
+
+		n += s.w.decodeFrames(buf[n:], data) * numOutputChannels
+		s.frame += numFrames
+	}
+	return n
+}
+
+// seek moves the stream to the given frame.
+func (s *stream) seek(frame int) error {
+	if _, err := s.r.Seek(s.dataOffset+int64(frame)*int64(s.w.blockAlign), io.SeekStart); err != nil {
+		return err
+	}
+	s.frame = frame
+	return nil
+}
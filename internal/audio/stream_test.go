@@ -0,0 +1,86 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// newTestWAV returns a 16-bit PCM WAV file with the given channels and samples.
+func newTestWAV(numChannels int, samples ...int16) []byte {
+	b := &bytes.Buffer{}
+	write := func(v interface{}) {
+		binary.Write(b, binary.LittleEndian, v)
+	}
+	b.WriteString("RIFF")
+	write(uint32(36 + len(samples)*2))
+	b.WriteString("WAVEfmt ")
+	write(uint32(16))
+	write(uint16(1))
+	write(uint16(numChannels))
+	write(uint32(sampleRate))
+	write(uint32(sampleRate * numChannels * 2))
+	write(uint16(numChannels * 2))
+	write(uint16(16))
+	b.WriteString("data")
+	write(uint32(len(samples) * 2))
+	write(samples)
+	return b.Bytes()
+}
+
+func TestStreamRead(t *testing.T) {
+	const (
+		a = 1 << 14
+		b = 2 << 12
+		c = 3 << 12
+	)
+	for _, tt := range []struct {
+		desc        string
+		data        []byte
+		loopStart   int
+		loopEnd     int
+		bufSize     int
+		wantSamples []int16
+	}{
+		{
+			desc:        "mono loops at end",
+			data:        newTestWAV(1, a, b, c),
+			bufSize:     8,
+			wantSamples: []int16{a, a, b, b, c, c, a, a},
+		},
+		{
+			desc:        "stereo loops after intro",
+			data:        newTestWAV(2, a, a, b, b, c, c),
+			loopStart:   1,
+			bufSize:     10,
+			wantSamples: []int16{a, a, b, b, c, c, b, b, c, c},
+		},
+		{
+			desc:        "loop end before data end",
+			data:        newTestWAV(1, a, b, c),
+			loopEnd:     2,
+			bufSize:     8,
+			wantSamples: []int16{a, a, b, b, a, a, b, b},
+		},
+	} {
+		s, err := newStream(bytes.NewReader(tt.data), tt.loopStart, tt.loopEnd)
+		if err != nil {
+			t.Errorf("[%s] newStream() returned error: %v", tt.desc, err)
+			continue
+		}
+
+		buf := make([]float32, tt.bufSize)
+		if n := s.read(buf); n != len(buf) {
+			t.Errorf("[%s] read() = %d, want %d", tt.desc, n, len(buf))
+		}
+
+		var want []float32
+		for _, v := range tt.wantSamples {
+			want = append(want, int16ToFloat(v))
+		}
+		if !reflect.DeepEqual(buf, want) {
+			t.Errorf("[%s] read() -> %v, want %v", tt.desc, buf, want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// maxSynthDuration is the longest a synthesized sound can be in seconds.
+const maxSynthDuration = 10
+
+// waveform is the shape of a synth layer's oscillator.
+type waveform int
+
+const (
+	waveSine waveform = iota
+	waveSquare
+	waveTriangle
+	waveSaw
+	waveNoise
+)
+
+// waveforms maps the names used in synth descriptions to waveforms.
+var waveforms = map[string]waveform{
+	"sine":     waveSine,
+	"square":   waveSquare,
+	"triangle": waveTriangle,
+	"saw":      waveSaw,
+	"noise":    waveNoise,
+}
+
+// synth is a sound effect described by layers of oscillators that are mixed together.
+//
+// Synth descriptions are text with one "key value" parameter per line and # comments.
+// Each "layer" line starts a new layer that the following parameters apply to:
+//
+//	layer
+//	wave square    # sine, square, triangle, saw, or noise
+//	freq 440       # starting frequency in Hz or noise sample rate in Hz
+//	sweep 880      # ending frequency in Hz or 0 to keep the starting frequency
+//	delay 0        # seconds before the layer starts
+//	duration 0.1   # seconds the layer is held before it is released
+//	attack 0.01    # seconds to rise to full volume
+//	decay 0.05     # seconds to fall to the sustain level
+//	sustain 0.5    # level from 0 to 1 held until the duration ends
+//	release 0.1    # seconds to fade out after the duration ends
+//	volume 0.8     # volume from 0 to 1 of the layer
+//	duty 0.5       # fraction of each square wave cycle that is high
+type synth struct {
+	layers []*synthLayer
+}
+
+// synthLayer is one oscillator with an envelope in a synth.
+type synthLayer struct {
+	wave     waveform
+	freq     float64
+	sweep    float64
+	delay    float64
+	duration float64
+	attack   float64
+	decay    float64
+	sustain  float64
+	release  float64
+	volume   float64
+	duty     float64
+}
+
+// newSynthLayer returns a layer with the default parameters.
+func newSynthLayer() *synthLayer {
+	return &synthLayer{
+		wave:     waveSine,
+		freq:     440,
+		duration: 0.1,
+		sustain:  1,
+		volume:   1,
+		duty:     0.5,
+	}
+}
+
+// decodeSynth decodes a synth description.
+func decodeSynth(r io.Reader) (*synth, error) {
+	s := &synth{}
+	var layer *synthLayer
+
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		fields := strings.Fields(stripComment(sc.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "layer" {
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("line %d: layer takes no value", lineNum)
+			}
+			layer = newSynthLayer()
+			s.layers = append(s.layers, layer)
+			continue
+		}
+
+		if layer == nil {
+			return nil, fmt.Errorf("line %d: %s before first layer", lineNum, fields[0])
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: %s should have one value", lineNum, fields[0])
+		}
+		if err := layer.set(fields[0], fields[1]); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(s.layers) == 0 {
+		return nil, fmt.Errorf("no layers")
+	}
+	for i, l := range s.layers {
+		if l.delay+l.duration+l.release > maxSynthDuration {
+			return nil, fmt.Errorf("layer %d: longer than %d seconds", i+1, maxSynthDuration)
+		}
+	}
+	return s, nil
+}
+
+// stripComment removes any comment from the line. Comments start with a # at the start of a word
+// so that notes like C#4 are not mistaken for comments.
+func stripComment(line string) string {
+	for i, r := range line {
+		if r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// set sets the layer's parameter with the given key to the value.
+func (l *synthLayer) set(key, value string) error {
+	if key == "wave" {
+		w, ok := waveforms[value]
+		if !ok {
+			return fmt.Errorf("unknown wave: %s", value)
+		}
+		l.wave = w
+		return nil
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+
+	var dst *float64
+	max := math.Inf(1)
+	switch key {
+	case "freq":
+		dst, max = &l.freq, sampleRate/2
+	case "sweep":
+		dst, max = &l.sweep, sampleRate/2
+	case "delay":
+		dst = &l.delay
+	case "duration":
+		dst = &l.duration
+	case "attack":
+		dst = &l.attack
+	case "decay":
+		dst = &l.decay
+	case "sustain":
+		dst, max = &l.sustain, 1
+	case "release":
+		dst = &l.release
+	case "volume":
+		dst, max = &l.volume, 1
+	case "duty":
+		dst, max = &l.duty, 1
+	default:
+		return fmt.Errorf("unknown parameter: %s", key)
+	}
+
+	if v < 0 || v > max || math.IsNaN(v) {
+		return fmt.Errorf("%s out of range: %v", key, v)
+	}
+	*dst = v
+	return nil
+}
+
+// render returns the synth's sound as interleaved stereo samples.
+func (s *synth) render() []float32 {
+	var numFrames int
+	for _, l := range s.layers {
+		if n := secondsToFrames(l.delay + l.duration + l.release); n > numFrames {
+			numFrames = n
+		}
+	}
+
+	mono := make([]float32, numFrames)
+	for _, l := range s.layers {
+		l.render(mono[secondsToFrames(l.delay):])
+	}
+
+	buf := make([]float32, numFrames*numOutputChannels)
+	for i, v := range mono {
+		buf[i*2], buf[i*2+1] = v, v
+	}
+	return buf
+}
+
+// render adds the layer's samples starting after its delay to buf.
+func (l *synthLayer) render(buf []float32) {
+	// Use the same noise each time so rendering is repeatable.
+	rnd := rand.New(rand.NewSource(1))
+	noise := rnd.Float64()*2 - 1
+
+	total := l.duration + l.release
+	numFrames := secondsToFrames(total)
+	if numFrames > len(buf) {
+		numFrames = len(buf)
+	}
+
+	phase := 0.0
+	for i := 0; i < numFrames; i++ {
+		t := float64(i) / sampleRate
+
+		var v float64
+		switch l.wave {
+		case waveSine:
+			v = math.Sin(2 * math.Pi * phase)
+		case waveSquare:
+			v = 1
+			if phase >= l.duty {
+				v = -1
+			}
+		case waveTriangle:
+			v = 1 - 4*math.Abs(phase-0.5)
+		case waveSaw:
+			v = 2*phase - 1
+		case waveNoise:
+			v = noise
+		}
+
+		buf[i] += float32(v * l.envelope(t) * l.volume)
+
+		// Advance the phase at the current frequency of the sweep.
+		phase += l.frequency(t/total) / sampleRate
+		if phase >= 1 {
+			phase -= math.Floor(phase)
+			noise = rnd.Float64()*2 - 1
+		}
+	}
+}
+
+// frequency returns the frequency at the fraction of the way through the layer.
+// Sweeps change exponentially so they sound even to the ear.
+func (l *synthLayer) frequency(fraction float64) float64 {
+	if l.sweep == 0 || l.freq == 0 {
+		return l.freq
+	}
+	return l.freq * math.Pow(l.sweep/l.freq, fraction)
+}
+
+// envelope returns the layer's volume from 0 to 1 at t seconds after it starts.
+func (l *synthLayer) envelope(t float64) float64 {
+	// level returns the level before the release.
+	level := func(t float64) float64 {
+		switch {
+		case t < l.attack:
+			return t / l.attack
+		case t < l.attack+l.decay:
+			return 1 - (1-l.sustain)*(t-l.attack)/l.decay
+		default:
+			return l.sustain
+		}
+	}
+
+	if t < l.duration {
+		return level(t)
+	}
+	if l.release == 0 {
+		return 0
+	}
+	return level(l.duration) * math.Max(0, 1-(t-l.duration)/l.release)
+}
+
+// secondsToFrames converts seconds to a number of frames at the output sample rate.
+func secondsToFrames(s float64) int {
+	return int(s * sampleRate)
+}
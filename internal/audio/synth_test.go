@@ -0,0 +1,168 @@
+package audio
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestDecodeSynth(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    []*synthLayer
+		wantErr bool
+	}{
+		{
+			desc: "defaults",
+			input: `
+# Comment before the layer.
+layer
+`,
+			want: []*synthLayer{newSynthLayer()},
+		},
+		{
+			desc: "two layers",
+			input: `
+layer
+wave square   # trailing comment
+freq 220
+sweep 440
+duty 0.25
+
+layer
+wave noise
+delay 0.5
+duration 0.2
+attack 0.01
+decay 0.02
+sustain 0.3
+release 0.4
+volume 0.5
+`,
+			want: []*synthLayer{
+				{wave: waveSquare, freq: 220, sweep: 440, duration: 0.1, sustain: 1, volume: 1, duty: 0.25},
+				{wave: waveNoise, freq: 440, delay: 0.5, duration: 0.2, attack: 0.01, decay: 0.02, sustain: 0.3, release: 0.4, volume: 0.5, duty: 0.5},
+			},
+		},
+		{
+			desc:    "no layers",
+			input:   "# Nothing",
+			wantErr: true,
+		},
+		{
+			desc:    "parameter before layer",
+			input:   "freq 440\nlayer",
+			wantErr: true,
+		},
+		{
+			desc:    "unknown parameter",
+			input:   "layer\npitch 440",
+			wantErr: true,
+		},
+		{
+			desc:    "unknown wave",
+			input:   "layer\nwave piano",
+			wantErr: true,
+		},
+		{
+			desc:    "bad number",
+			input:   "layer\nfreq loud",
+			wantErr: true,
+		},
+		{
+			desc:    "out of range",
+			input:   "layer\nvolume 2",
+			wantErr: true,
+		},
+		{
+			desc:    "missing value",
+			input:   "layer\nfreq",
+			wantErr: true,
+		},
+		{
+			desc:    "too long",
+			input:   "layer\nduration 60",
+			wantErr: true,
+		},
+	} {
+		got, err := decodeSynth(strings.NewReader(tt.input))
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("[%s] decodeSynth: got error %v, want nil", tt.desc, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("[%s] decodeSynth: got nil error, want error", tt.desc)
+			continue
+		}
+		if diff := pretty.Compare(got.layers, tt.want); diff != "" {
+			t.Errorf("[%s] decodeSynth layers differ:\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestSynthLayerEnvelope(t *testing.T) {
+	l := &synthLayer{duration: 0.3, attack: 0.1, decay: 0.1, sustain: 0.5, release: 0.2}
+	for _, tt := range []struct {
+		t    float64
+		want float64
+	}{
+		{0, 0},
+		{0.05, 0.5},
+		{0.1, 1},
+		{0.15, 0.75},
+		{0.25, 0.5},
+		{0.4, 0.25},
+		{0.5, 0},
+		{0.6, 0},
+	} {
+		if got := l.envelope(tt.t); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("envelope(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestSynthRender(t *testing.T) {
+	s, err := decodeSynth(strings.NewReader(`
+layer
+wave square
+freq 100
+duration 0.1
+volume 0.5
+
+layer
+wave noise
+delay 0.2
+duration 0.1
+release 0.1
+`))
+	if err != nil {
+		t.Fatalf("decodeSynth: %v", err)
+	}
+
+	buf := s.render()
+	if got, want := len(buf), secondsToFrames(0.4)*numOutputChannels; got != want {
+		t.Fatalf("len(render()) = %d, want %d", got, want)
+	}
+
+	// The square wave starts high at half volume and both channels match.
+	if buf[0] != 0.5 || buf[1] != 0.5 {
+		t.Errorf("render()[0:2] = %v, want [0.5 0.5]", buf[:2])
+	}
+
+	// There is silence between the layers.
+	for i := secondsToFrames(0.1) * 2; i < secondsToFrames(0.2)*2; i++ {
+		if buf[i] != 0 {
+			t.Fatalf("render()[%d] = %v, want 0", i, buf[i])
+		}
+	}
+
+	// Rendering again gives the same noise.
+	if diff := pretty.Compare(s.render(), buf); diff != "" {
+		t.Errorf("render() is not repeatable:\n%s", diff)
+	}
+}
@@ -1,88 +1,392 @@
 package audio
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 )
 
+// Audio formats found in the fmt chunk.
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// maxWAVDataSize is the largest data chunk that decodeWAV loads into memory.
+const maxWAVDataSize = 64 << 20
+
+// maxWAVFormatSize is the most of a fmt chunk that is read, which is the size of an extensible fmt chunk.
+// Anything after it is skipped so that a corrupt size cannot allocate a huge buffer.
+const maxWAVFormatSize = 40
+
+// maxWAVChunkSize is the largest chunk that the decoder will skip over or read.
+const maxWAVChunkSize = 1 << 30
+
+// channelGains are how much each channel of a WAV file contributes to the left and right
+// output channels in the default WAV channel order. Other channels are ignored.
+var channelGains = [...][numOutputChannels]float32{
+	{1, 0},         // front left
+	{0, 1},         // front right
+	{0.707, 0.707}, // front center
+	{0, 0},         // low frequency
+	{0.707, 0},     // back left
+	{0, 0.707},     // back right
+	{0.707, 0},     // front left of center
+	{0, 0.707},     // front right of center
+}
+
 // wav is decoded WAV data.
 //
-// https://github.com/verdverm/go-wav/blob/master/wav.go
 // http://soundfile.sapp.org/doc/WaveFormat/
+// https://www.mmsp.ece.mcgill.ca/Documents/AudioFormats/WAVE/WAVE.html
 type wav struct {
-	chunkID   [4]byte
-	chunkSize uint32
-	format    [4]byte
-
-	subchunk1ID   [4]byte
-	subchunk1Size uint32
+	// audioFormat is wavFormatPCM or wavFormatFloat. Extensible formats are resolved to either.
 	audioFormat   uint16
 	numChannels   uint16
 	sampleRate    uint32
-	byteRate      uint32
 	blockAlign    uint16
 	bitsPerSample uint16
 
-	subchunk2ID   [4]byte
-	subchunk2Size uint32
-	data          []byte
+	// mixGains are how much each channel contributes to the left and right output channels.
+	mixGains [][numOutputChannels]float32
+
+	// dataSize is the size in bytes of the data chunk rounded down to whole frames.
+	dataSize uint32
+
+	// data is the raw data of the data chunk. It is only set by decodeWAV.
+	data []byte
 }
 
+// decodeWAV decodes the header and all the data of a WAV file.
 func decodeWAV(r io.Reader) (*wav, error) {
-	w := &wav{}
-	var err error
+	w, err := decodeWAVHeader(r)
+	if err != nil {
+		return nil, err
+	}
 
-	read := func(order binary.ByteOrder, dst interface{}) {
-		if err != nil {
-			return
-		}
-		err = binary.Read(r, order, dst)
+	if w.dataSize > maxWAVDataSize {
+		return nil, fmt.Errorf("data chunk too big: %d bytes", w.dataSize)
+	}
+
+	w.data = make([]byte, w.dataSize)
+	if _, err := io.ReadFull(r, w.data); err != nil {
+		return nil, fmt.Errorf("reading data chunk: %v", err)
+	}
+	return w, nil
+}
+
+// decodeWAVHeader decodes the WAV header and leaves the reader at the start of the data.
+// It skips any chunks it does not need like LIST, fact, or cue chunks.
+func decodeWAVHeader(r io.Reader) (*wav, error) {
+	var riff struct {
+		ID     [4]byte
+		Size   uint32
+		Format [4]byte
+	}
+	if err := binary.Read(r, binary.LittleEndian, &riff); err != nil {
+		return nil, fmt.Errorf("reading RIFF header: %v", err)
 	}
+	if string(riff.ID[:]) != "RIFF" {
+		return nil, fmt.Errorf("chunkID should be %q, got %q", "RIFF", riff.ID[:])
+	}
+	if string(riff.Format[:]) != "WAVE" {
+		return nil, fmt.Errorf("format should be %q, got %q", "WAVE", riff.Format[:])
+	}
+
+	// remaining is how many bytes are left in the RIFF chunk after the format.
+	remaining := int64(riff.Size) - 4
 
-	check := func(name string, value [4]byte, want string) {
-		if err != nil {
-			return
+	var w *wav
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
 		}
-		if str := string(value[:]); str != want {
-			err = fmt.Errorf("%s should be %q, got %q", name, want, str)
+		if err := binary.Read(r, binary.LittleEndian, &chunk); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("missing data chunk")
+			}
+			return nil, fmt.Errorf("reading chunk header: %v", err)
+		}
+		remaining -= 8
+
+		id := string(chunk.ID[:])
+		if chunk.Size > maxWAVChunkSize {
+			return nil, fmt.Errorf("%q chunk too big: %d bytes", id, chunk.Size)
 		}
-	}
 
-	// Chunk 1 - RIFF
+		switch id {
+		case "fmt ":
+			if w != nil {
+				return nil, fmt.Errorf("duplicate fmt chunk")
+			}
+			var err error
+			if w, err = decodeWAVFormat(r, chunk.Size); err != nil {
+				return nil, err
+			}
 
-	read(binary.BigEndian, &w.chunkID)
-	read(binary.LittleEndian, &w.chunkSize)
-	read(binary.BigEndian, &w.format)
-	check("chunkID", w.chunkID, "RIFF")
-	check("format", w.format, "WAVE")
+		case "data":
+			if w == nil {
+				return nil, fmt.Errorf("data chunk before fmt chunk")
+			}
 
-	// Chunk 2 - fmt
+			// Trust the RIFF size over the data size when writers leave the latter unfinished.
+			size := int64(chunk.Size)
+			if remaining >= 0 && size > remaining {
+				size = remaining
+			}
+			w.dataSize = uint32(size - size%int64(w.blockAlign))
+			return w, nil
 
-	read(binary.BigEndian, &w.subchunk1ID)
-	read(binary.LittleEndian, &w.subchunk1Size)
-	read(binary.LittleEndian, &w.audioFormat)
-	read(binary.LittleEndian, &w.numChannels)
-	read(binary.LittleEndian, &w.sampleRate)
-	read(binary.LittleEndian, &w.byteRate)
-	read(binary.LittleEndian, &w.blockAlign)
-	read(binary.LittleEndian, &w.bitsPerSample)
-	check("subchunk1ID", w.subchunk1ID, "fmt ")
+		default:
+			if _, err := io.CopyN(ioutil.Discard, r, int64(chunk.Size)); err != nil {
+				return nil, fmt.Errorf("skipping %q chunk: %v", id, err)
+			}
+		}
 
-	// Chunk 3 - data
+		// Chunks are padded to an even number of bytes.
+		if chunk.Size%2 == 1 {
+			if _, err := io.CopyN(ioutil.Discard, r, 1); err != nil {
+				return nil, fmt.Errorf("skipping %q chunk padding: %v", id, err)
+			}
+		}
+		remaining -= int64(chunk.Size + chunk.Size%2)
+	}
+}
 
-	read(binary.BigEndian, &w.subchunk2ID)
-	read(binary.LittleEndian, &w.subchunk2Size)
-	w.data = make([]byte, w.subchunk2Size)
-	read(binary.LittleEndian, &w.data)
-	check("subchunk2ID", w.subchunk2ID, "data")
+// decodeWAVFormat decodes and validates a fmt chunk of the given size.
+func decodeWAVFormat(r io.Reader, size uint32) (*wav, error) {
+	if size < 16 {
+		return nil, fmt.Errorf("fmt chunk too small: %d bytes", size)
+	}
 
-	if err != nil {
+	n := size
+	if n > maxWAVFormatSize {
+		n = maxWAVFormatSize
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("reading fmt chunk: %v", err)
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, int64(size-n)); err != nil {
+		return nil, fmt.Errorf("skipping rest of fmt chunk: %v", err)
+	}
+
+	var f struct {
+		AudioFormat   uint16
+		NumChannels   uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &f); err != nil {
 		return nil, err
 	}
+
+	// Extensible formats store the real format in the first two bytes of the subformat GUID.
+	if f.AudioFormat == wavFormatExtensible {
+		if size < 40 {
+			return nil, fmt.Errorf("extensible fmt chunk too small: %d bytes", size)
+		}
+		f.AudioFormat = binary.LittleEndian.Uint16(data[24:])
+	}
+
+	w := &wav{
+		audioFormat:   f.AudioFormat,
+		numChannels:   f.NumChannels,
+		sampleRate:    f.SampleRate,
+		blockAlign:    f.BlockAlign,
+		bitsPerSample: f.BitsPerSample,
+	}
+
+	switch {
+	case w.audioFormat == wavFormatPCM && (w.bitsPerSample == 8 || w.bitsPerSample == 16 || w.bitsPerSample == 24 || w.bitsPerSample == 32):
+	case w.audioFormat == wavFormatFloat && (w.bitsPerSample == 32 || w.bitsPerSample == 64):
+	default:
+		return nil, fmt.Errorf("unsupported format: %d %d-bit", w.audioFormat, w.bitsPerSample)
+	}
+
+	if w.numChannels == 0 || int(w.numChannels) > len(channelGains) {
+		return nil, fmt.Errorf("unsupported number of channels: %d", w.numChannels)
+	}
+	w.mixGains = mixGains(int(w.numChannels))
+
+	if w.sampleRate == 0 || w.sampleRate > 384000 {
+		return nil, fmt.Errorf("invalid sample rate: %d", w.sampleRate)
+	}
+
+	if w.blockAlign != w.numChannels*w.bitsPerSample/8 {
+		return nil, fmt.Errorf("invalid block align: %d", w.blockAlign)
+	}
+
 	return w, nil
 }
 
+// numFrames returns how many frames are in the data chunk.
+func (w *wav) numFrames() int {
+	return int(w.dataSize) / int(w.blockAlign)
+}
+
+// mixGains returns the gains to mix the given number of channels down or up to stereo.
+func mixGains(numChannels int) [][numOutputChannels]float32 {
+	// Play mono on both output channels at full volume.
+	if numChannels == 1 {
+		return [][numOutputChannels]float32{{1, 1}}
+	}
+
+	gains := make([][numOutputChannels]float32, numChannels)
+	copy(gains, channelGains[:numChannels])
+
+	// Scale the gains down so that the mix of full scale channels never exceeds 1.
+	var total [numOutputChannels]float32
+	for _, g := range gains {
+		for c := range g {
+			total[c] += g[c]
+		}
+	}
+	for i := range gains {
+		for c := range gains[i] {
+			if total[c] > 1 {
+				gains[i][c] /= total[c]
+			}
+		}
+	}
+	return gains
+}
+
+// samples returns the data as interleaved stereo samples from -1 to 1 at the output sample rate.
+func (w *wav) samples() []float32 {
+	buf := make([]float32, w.numFrames()*numOutputChannels)
+	w.decodeFrames(buf, w.data)
+	return resample(buf, int(w.sampleRate), sampleRate)
+}
+
+// decodeFrames decodes whole frames of data into interleaved stereo samples from -1 to 1
+// by mixing the channels according to mixGains. It returns how many frames were decoded.
+func (w *wav) decodeFrames(buf []float32, data []byte) int {
+	bytesPerSample := int(w.bitsPerSample / 8)
+	n := 0
+	for ; len(data) >= int(w.blockAlign) && len(buf) >= numOutputChannels; n++ {
+		var out [numOutputChannels]float32
+		for i, g := range w.mixGains {
+			v := w.decodeSample(data[i*bytesPerSample:])
+			for c := range out {
+				out[c] += v * g[c]
+			}
+		}
+		copy(buf, out[:])
+		buf = buf[numOutputChannels:]
+		data = data[w.blockAlign:]
+	}
+	return n
+}
+
+// decodeSample decodes the sample at the start of data into a sample from -1 to 1.
+func (w *wav) decodeSample(data []byte) float32 {
+	if w.audioFormat == wavFormatFloat {
+		if w.bitsPerSample == 64 {
+			return float32(math.Float64frombits(binary.LittleEndian.Uint64(data)))
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	}
+
+	switch w.bitsPerSample {
+	case 8:
+		// 8-bit samples are unsigned unlike the others.
+		return float32(int(data[0])-128) / 128
+	case 16:
+		return int16ToFloat(int16(binary.LittleEndian.Uint16(data)))
+	case 24:
+		v := int32(uint32(data[0])<<8|uint32(data[1])<<16|uint32(data[2])<<24) >> 8
+		return float32(v) / (1 << 23)
+	default:
+		return float32(int32(binary.LittleEndian.Uint32(data))) / (1 << 31)
+	}
+}
+
 func (w *wav) String() string {
-	return fmt.Sprintf("numChannels: %d sampleRate: %d bitsPerSample: %d len(data): %d", w.numChannels, w.sampleRate, w.bitsPerSample, len(w.data))
+	return fmt.Sprintf("audioFormat: %d numChannels: %d sampleRate: %d bitsPerSample: %d dataSize: %d", w.audioFormat, w.numChannels, w.sampleRate, w.bitsPerSample, w.dataSize)
+}
+
+// wavWriter writes 16-bit stereo samples at the output sample rate to a WAV file.
+type wavWriter struct {
+	// w is where the WAV file is written to.
+	w io.WriteSeeker
+
+	// dataSize is how many bytes of samples have been written so far.
+	dataSize uint32
+}
+
+// newWAVWriter writes a WAV header to w and returns a wavWriter to write samples after it.
+// The sizes in the header are filled in by close.
+func newWAVWriter(w io.WriteSeeker) (*wavWriter, error) {
+	ww := &wavWriter{w: w}
+	if err := ww.writeHeader(); err != nil {
+		return nil, err
+	}
+	return ww, nil
+}
+
+// write appends the interleaved stereo samples to the file.
+func (ww *wavWriter) write(samples []int16) error {
+	if err := binary.Write(ww.w, binary.LittleEndian, samples); err != nil {
+		return err
+	}
+	ww.dataSize += uint32(len(samples) * 2)
+	return nil
+}
+
+// close rewrites the header with the final sizes and closes the file if it is a Closer.
+// The file is closed even if the header cannot be rewritten.
+func (ww *wavWriter) close() error {
+	_, err := ww.w.Seek(0, io.SeekStart)
+	if err == nil {
+		err = ww.writeHeader()
+	}
+	if c, ok := ww.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// writeHeader writes the header for the samples written so far.
+func (ww *wavWriter) writeHeader() error {
+	const blockAlign = numOutputChannels * 2
+	header := struct {
+		ChunkID       [4]byte
+		ChunkSize     uint32
+		Format        [4]byte
+		Subchunk1ID   [4]byte
+		Subchunk1Size uint32
+		AudioFormat   uint16
+		NumChannels   uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Subchunk2ID   [4]byte
+		Subchunk2Size uint32
+	}{
+		ChunkID:       [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     36 + ww.dataSize,
+		Format:        [4]byte{'W', 'A', 'V', 'E'},
+		Subchunk1ID:   [4]byte{'f', 'm', 't', ' '},
+		Subchunk1Size: 16,
+		AudioFormat:   wavFormatPCM,
+		NumChannels:   numOutputChannels,
+		SampleRate:    sampleRate,
+		ByteRate:      sampleRate * blockAlign,
+		BlockAlign:    blockAlign,
+		BitsPerSample: 16,
+		Subchunk2ID:   [4]byte{'d', 'a', 't', 'a'},
+		Subchunk2Size: ww.dataSize,
+	}
+	return binary.Write(ww.w, binary.LittleEndian, &header)
 }
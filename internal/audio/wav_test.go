@@ -0,0 +1,257 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// riffChunk returns a chunk with the given ID and little endian data padded to an even size.
+func riffChunk(id string, data ...interface{}) []byte {
+	body := &bytes.Buffer{}
+	for _, d := range data {
+		binary.Write(body, binary.LittleEndian, d)
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(id)
+	binary.Write(b, binary.LittleEndian, uint32(body.Len()))
+	b.Write(body.Bytes())
+	if body.Len()%2 == 1 {
+		b.WriteByte(0)
+	}
+	return b.Bytes()
+}
+
+// riffFile returns a RIFF WAVE file with the given chunks.
+func riffFile(chunks ...[]byte) []byte {
+	body := bytes.Join(chunks, nil)
+	b := &bytes.Buffer{}
+	b.WriteString("RIFF")
+	binary.Write(b, binary.LittleEndian, uint32(4+len(body)))
+	b.WriteString("WAVE")
+	b.Write(body)
+	return b.Bytes()
+}
+
+// fmtChunk returns a basic fmt chunk.
+func fmtChunk(audioFormat, numChannels, bitsPerSample int) []byte {
+	blockAlign := numChannels * bitsPerSample / 8
+	return riffChunk("fmt ",
+		uint16(audioFormat),
+		uint16(numChannels),
+		uint32(sampleRate),
+		uint32(sampleRate*blockAlign),
+		uint16(blockAlign),
+		uint16(bitsPerSample))
+}
+
+func TestDecodeWAV(t *testing.T) {
+	extensibleFmt := riffChunk("fmt ",
+		uint16(wavFormatExtensible),
+		uint16(1),
+		uint32(sampleRate),
+		uint32(sampleRate*3),
+		uint16(3),
+		uint16(24),
+		uint16(22),           // cbSize
+		uint16(24),           // validBitsPerSample
+		uint32(4),            // channelMask
+		uint16(wavFormatPCM), // subformat GUID
+		[14]byte{})
+
+	for _, tt := range []struct {
+		desc        string
+		data        []byte
+		wantSamples []float32
+		wantErr     bool
+	}{
+		{
+			desc:        "16-bit mono",
+			data:        newTestWAV(1, 1<<14, -1<<14),
+			wantSamples: []float32{0.5, 0.5, -0.5, -0.5},
+		},
+		{
+			desc:        "16-bit stereo",
+			data:        newTestWAV(2, 1<<14, -1<<14),
+			wantSamples: []float32{0.5, -0.5},
+		},
+		{
+			desc:        "8-bit unsigned",
+			data:        riffFile(fmtChunk(wavFormatPCM, 1, 8), riffChunk("data", []uint8{192, 64, 128})),
+			wantSamples: []float32{0.5, 0.5, -0.5, -0.5, 0, 0},
+		},
+		{
+			desc:        "24-bit stereo",
+			data:        riffFile(fmtChunk(wavFormatPCM, 2, 24), riffChunk("data", []uint8{0, 0, 0x40, 0, 0, 0xC0})),
+			wantSamples: []float32{0.5, -0.5},
+		},
+		{
+			desc:        "32-bit float",
+			data:        riffFile(fmtChunk(wavFormatFloat, 2, 32), riffChunk("data", []float32{0.25, -0.75})),
+			wantSamples: []float32{0.25, -0.75},
+		},
+		{
+			desc:        "64-bit float",
+			data:        riffFile(fmtChunk(wavFormatFloat, 1, 64), riffChunk("data", []float64{0.25})),
+			wantSamples: []float32{0.25, 0.25},
+		},
+		{
+			desc:        "extensible format",
+			data:        riffFile(extensibleFmt, riffChunk("data", []uint8{0, 0, 0x40})),
+			wantSamples: []float32{0.5, 0.5},
+		},
+		{
+			desc: "skip unknown chunks with padding",
+			data: riffFile(
+				riffChunk("LIST", []byte("INFOabc")),
+				fmtChunk(wavFormatPCM, 2, 16),
+				riffChunk("fact", uint32(1)),
+				riffChunk("cue ", uint32(0)),
+				riffChunk("data", []int16{1 << 14, -1 << 14})),
+			wantSamples: []float32{0.5, -0.5},
+		},
+		{
+			desc:        "partial frame ignored",
+			data:        riffFile(fmtChunk(wavFormatPCM, 2, 16), riffChunk("data", []int16{1 << 14, -1 << 14, 1 << 14})),
+			wantSamples: []float32{0.5, -0.5},
+		},
+		{
+			desc:        "5.1 downmix",
+			data:        riffFile(fmtChunk(wavFormatPCM, 6, 16), riffChunk("data", []int16{1 << 14, 0, 1 << 14, 1 << 14, 0, 0})),
+			wantSamples: []float32{0.3536, 0.1464},
+		},
+		{
+			desc:    "not RIFF",
+			data:    []byte("RIFX\x04\x00\x00\x00WAVE"),
+			wantErr: true,
+		},
+		{
+			desc:    "missing data chunk",
+			data:    riffFile(fmtChunk(wavFormatPCM, 1, 16)),
+			wantErr: true,
+		},
+		{
+			desc:    "data before fmt",
+			data:    riffFile(riffChunk("data", []int16{0}), fmtChunk(wavFormatPCM, 1, 16)),
+			wantErr: true,
+		},
+		{
+			desc:    "unsupported bits per sample",
+			data:    riffFile(fmtChunk(wavFormatPCM, 1, 12), riffChunk("data", []int16{0})),
+			wantErr: true,
+		},
+		{
+			desc:    "unsupported channels",
+			data:    riffFile(fmtChunk(wavFormatPCM, 9, 16), riffChunk("data", make([]int16, 9))),
+			wantErr: true,
+		},
+		{
+			desc:    "truncated data",
+			data:    newTestWAV(1, 1, 2, 3)[:46],
+			wantErr: true,
+		},
+		{
+			desc: "fmt chunk with extra bytes",
+			data: riffFile(
+				riffChunk("fmt ",
+					uint16(wavFormatPCM),
+					uint16(2),
+					uint32(sampleRate),
+					uint32(sampleRate*4),
+					uint16(4),
+					uint16(16),
+					[34]byte{}),
+				riffChunk("data", []int16{1 << 14, -1 << 14})),
+			wantSamples: []float32{0.5, -0.5},
+		},
+		{
+			desc: "huge fmt chunk size",
+			data: riffFile(
+				append([]byte("fmt \x00\x00\x00\x3f"), fmtChunk(wavFormatPCM, 1, 16)[8:]...),
+				riffChunk("data", []int16{0})),
+			wantErr: true,
+		},
+		{
+			desc: "absurd chunk size",
+			data: riffFile(
+				fmtChunk(wavFormatPCM, 1, 16),
+				[]byte("data\xff\xff\xff\xff")),
+			wantErr: true,
+		},
+	} {
+		w, err := decodeWAV(bytes.NewReader(tt.data))
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("[%s] decodeWAV: got error %v, want nil", tt.desc, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("[%s] decodeWAV: got nil error, want error", tt.desc)
+			continue
+		}
+
+		samples := w.samples()
+		for i, v := range samples {
+			samples[i] = float32(math.Floor(float64(v)*1e4+0.5) / 1e4)
+		}
+		if diff := pretty.Compare(samples, tt.wantSamples); diff != "" {
+			t.Errorf("[%s] samples differ:\n%s", tt.desc, diff)
+		}
+	}
+}
+
+// failingSeeker is a file whose Seek fails, which records whether it was closed.
+type failingSeeker struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (f *failingSeeker) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.New("seek failed")
+}
+
+func (f *failingSeeker) Close() error {
+	f.closed = true
+	return errors.New("close failed")
+}
+
+func TestDecodeWAVHugeFormat(t *testing.T) {
+	// A fmt chunk that claims to be almost 1 GiB should fail without allocating all of it.
+	data := riffFile(
+		append([]byte("fmt \x00\x00\x00\x3f"), fmtChunk(wavFormatPCM, 1, 16)[8:]...),
+		riffChunk("data", []int16{0}))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := decodeWAV(bytes.NewReader(data)); err == nil {
+		t.Errorf("decodeWAV: got nil error, want error")
+	}
+	runtime.ReadMemStats(&after)
+
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("decodeWAV allocated %d bytes, want at most %d", n, 1<<20)
+	}
+}
+
+func TestWAVWriterCloseSeekError(t *testing.T) {
+	f := &failingSeeker{}
+	ww, err := newWAVWriter(f)
+	if err != nil {
+		t.Fatalf("newWAVWriter: %v", err)
+	}
+
+	// The seek error is returned over the close error, but the file is still closed.
+	if err := ww.close(); err == nil || err.Error() != "seek failed" {
+		t.Errorf("close() = %v, want seek failed", err)
+	}
+	if !f.closed {
+		t.Errorf("file not closed after seek failed")
+	}
+}
@@ -1,6 +1,6 @@
 package game
 
-import "github.com/btmura/blockcillin/internal/audio"
+import "math/rand"
 
 // Block is a block that can be put into a cell.
 type Block struct {
@@ -10,6 +10,9 @@ type Block struct {
 	// Color is the block's color. Red by default.
 	Color BlockColor
 
+	// Kind is the block's kind. Normal by default.
+	Kind BlockKind
+
 	// swapID is a temporary non-zero ID to associate this block with a specific swap move.
 	swapID int
 
@@ -103,11 +106,50 @@ const (
 
 const maxBlockColors = 6
 
+//go:generate stringer -type=BlockKind
+type BlockKind int32
+
+const (
+	// BlockNormal is a block that only matches blocks of the same color.
+	BlockNormal BlockKind = iota
+
+	// BlockRainbow is a block that matches blocks of any color.
+	BlockRainbow
+
+	// BlockBomb is a block that clears its whole ring when matched.
+	BlockBomb
+
+	// BlockLocked is a block that cannot be swapped or matched.
+	// It becomes a normal block when an adjacent block is matched.
+	BlockLocked
+)
+
+// blockKindChances maps kinds to the chance that a new block will be that kind.
+var blockKindChances = [...]float32{
+	BlockRainbow: 0.02,
+	BlockBomb:    0.01,
+	BlockLocked:  0.04,
+}
+
+// randomBlockKind returns a random block kind using blockKindChances.
+func randomBlockKind() BlockKind {
+	v := rand.Float32()
+	for k, chance := range blockKindChances {
+		if v < chance {
+			return BlockKind(k)
+		}
+		v -= chance
+	}
+	return BlockNormal
+}
+
 // swap swaps the left block with the right block.
-func (l *Block) swap(r *Block, swapID int) {
-	if blockStateSwappable[l.State] && blockStateSwappable[r.State] {
+// It returns true if any visible blocks were swapped.
+func (l *Block) swap(r *Block, swapID int) bool {
+	if l.swappable() && r.swappable() {
 		l.State, r.State = r.State, l.State
 		l.Color, r.Color = r.Color, l.Color
+		l.Kind, r.Kind = r.Kind, l.Kind
 		l.swapID, r.swapID = swapID, swapID
 		l.Dropping, r.Dropping = false, false
 
@@ -129,16 +171,16 @@ func (l *Block) swap(r *Block, swapID int) {
 			r.setState(BlockCleared)
 		}
 
-		if numBlocks > 0 {
-			audio.Play(audio.SoundSwap)
-		}
+		return numBlocks > 0
 	}
+	return false
 }
 
 // drop drops the upper block into the lower block.
 func (u *Block) drop(d *Block) {
 	if u.State == BlockStatic && d.State == BlockCleared {
 		u.Color, d.Color = d.Color, u.Color
+		u.Kind, d.Kind = d.Kind, u.Kind
 		u.swapID, d.swapID = 0, 0
 		u.Dropping, d.Dropping = d.Dropping, u.Dropping
 
@@ -147,6 +189,11 @@ func (u *Block) drop(d *Block) {
 	}
 }
 
+// swappable returns whether the block can be swapped in its current state.
+func (b *Block) swappable() bool {
+	return blockStateSwappable[b.State] && b.Kind != BlockLocked
+}
+
 // update advances the state machine by one update.
 func (b *Block) update() {
 	advance := func(nextState BlockState) bool {
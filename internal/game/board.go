@@ -1,10 +1,6 @@
 package game
 
-import (
-	"math/rand"
-
-	"github.com/btmura/blockcillin/internal/audio"
-)
+import "math/rand"
 
 const (
 	minRiseRate           = 0.005
@@ -13,6 +9,9 @@ const (
 	maxSpeed              = 100
 	riseRateDelta         = (maxRiseRate - minRiseRate) / float32(maxSpeed)
 	requiredBlocksCleared = 30
+
+	// dangerRingCount is how many rings at the top put the board in danger when they have blocks.
+	dangerRingCount = 2
 )
 
 type Board struct {
@@ -40,6 +39,9 @@ type Board struct {
 	// numBlockColors is how many colors the blocks the board's blocks can be.
 	numBlockColors int
 
+	// specialBlocks is whether new rings can have rainbow, bomb, and locked blocks.
+	specialBlocks bool
+
 	// matches contains matches that are being cleared.
 	matches []*match
 
@@ -63,6 +65,9 @@ type Board struct {
 
 	// swapIDCounter is the next non-zero swap ID to set on the next swapped blocks.
 	swapIDCounter int
+
+	// danger is whether blocks are in the top rings of the board.
+	danger bool
 }
 
 type Ring struct {
@@ -101,7 +106,7 @@ type chainLink struct {
 	level int
 }
 
-func newBoard(numBlockColors, speed int) *Board {
+func newBoard(numBlockColors, speed int, specialBlocks bool) *Board {
 	const (
 		ringCount       = 10
 		cellCount       = 15
@@ -113,6 +118,7 @@ func newBoard(numBlockColors, speed int) *Board {
 		RingCount:      ringCount,
 		CellCount:      cellCount,
 		numBlockColors: numBlockColors,
+		specialBlocks:  specialBlocks,
 		speed:          speed,
 	}
 
@@ -124,32 +130,45 @@ func newBoard(numBlockColors, speed int) *Board {
 
 	for i := 0; i < b.RingCount; i++ {
 		invisible := i < b.RingCount-filledRingCount
-		b.Rings = append(b.Rings, newRing(b.CellCount, numBlockColors, invisible))
+		b.Rings = append(b.Rings, b.newRing(invisible))
 	}
 
 	for i := 0; i < spareRingCount; i++ {
-		b.SpareRings = append(b.SpareRings, newRing(b.CellCount, numBlockColors, false))
+		b.SpareRings = append(b.SpareRings, b.newRing(false))
 	}
 
 	// Position the selector at the first filled ring.
 	b.Selector = newSelector(b.RingCount, b.CellCount)
 	b.Selector.Y = b.RingCount - filledRingCount
 
+	publish(Event{
+		Type:      EventNewBoard,
+		X:         b.Selector.X,
+		Y:         b.Selector.Y,
+		CellCount: b.CellCount,
+		Speed:     speed,
+	})
+
 	return b
 }
 
-// TODO(btmura): make newRing into a method
-func newRing(cellCount, numBlockColors int, invisible bool) *Ring {
+func (b *Board) newRing(invisible bool) *Ring {
 	r := &Ring{}
-	for i := 0; i < cellCount; i++ {
+	for i := 0; i < b.CellCount; i++ {
 		state := BlockStatic
-		if invisible {
+		kind := BlockNormal
+		switch {
+		case invisible:
 			state = BlockCleared
+
+		case b.specialBlocks:
+			kind = randomBlockKind()
 		}
 		c := &Cell{
 			Block: &Block{
 				State: state,
-				Color: BlockColor(rand.Intn(numBlockColors)),
+				Color: BlockColor(rand.Intn(b.numBlockColors)),
+				Kind:  kind,
 			},
 			Marker: &Marker{},
 		}
@@ -200,7 +219,9 @@ func (b *Board) swap() {
 
 	li, ri := x, (x+1)%b.CellCount
 	lc, rc := b.cellAt(li, y), b.cellAt(ri, y)
-	lc.Block.swap(rc.Block, b.nextSwapID())
+	if lc.Block.swap(rc.Block, b.nextSwapID()) {
+		publish(Event{Type: EventSwap, X: x, Y: y})
+	}
 }
 
 func (b *Board) exit() {
@@ -234,6 +255,7 @@ func (b *Board) update() {
 
 		b.addNewMatches()
 		b.updateMatches()
+		b.updateDanger()
 
 		// Reset swap IDs for stationary blocks after new matches have been found.
 		for _, r := range b.Rings {
@@ -245,8 +267,9 @@ func (b *Board) update() {
 		}
 
 		if b.numSpeedBlocksCleared > requiredBlocksCleared {
-			if b.speed++; b.speed > maxSpeed {
-				b.speed = maxSpeed
+			if b.speed < maxSpeed {
+				b.speed++
+				publish(Event{Type: EventSpeedUp, Speed: b.speed})
 			}
 			b.numSpeedBlocksCleared = 0
 		}
@@ -269,11 +292,11 @@ func (b *Board) update() {
 		b.chainLinks = nil
 
 		// Reset dropping flag since we are rising again.
-		for _, r := range b.Rings {
-			for _, c := range r.Cells {
+		for y, r := range b.Rings {
+			for x, c := range r.Cells {
 				if c.Block.Dropping {
 					c.Block.Dropping = false
-					audio.Play(audio.SoundThud)
+					publish(Event{Type: EventLand, X: x, Y: y})
 				}
 			}
 		}
@@ -291,6 +314,7 @@ func (b *Board) update() {
 			for _, c := range b.Rings[0].Cells {
 				if c.Block.State != BlockCleared {
 					b.setState(BoardGameOver)
+					publish(Event{Type: EventGameOver})
 					return
 				}
 			}
@@ -301,7 +325,7 @@ func (b *Board) update() {
 			b.Rings = append(b.Rings[1:], b.SpareRings[0])
 
 			// Add a new spare ring, since one was taken away.
-			b.SpareRings = append(b.SpareRings[1:], newRing(b.CellCount, b.numBlockColors, false))
+			b.SpareRings = append(b.SpareRings[1:], b.newRing(false))
 
 			// Adjust the selector down in case it was at the removed top ring.
 			if b.Selector.Y--; b.Selector.Y < 0 {
@@ -344,13 +368,15 @@ func (b *Board) addNewMatches() {
 			hasDroppedBlock = hasDroppedBlock || block.Dropping
 			if block.Dropping {
 				block.Dropping = false
-				audio.Play(audio.SoundThud)
+				publish(Event{Type: EventLand, X: c.x, Y: c.y})
 			}
 
 			b.numUpdateBlocksCleared++
 			b.numSpeedBlocksCleared++
 		}
 
+		b.unlockAdjacentBlocks(m)
+
 		var link *chainLink
 		if hasDroppedBlock {
 		linkLoop:
@@ -375,9 +401,18 @@ func (b *Board) addNewMatches() {
 			link.level++
 		}
 
+		m.chainLevel = link.level
 		link.nextMatches = append(link.nextMatches, m)
 		dirtyLinks = append(dirtyLinks, link)
 		b.markerAt(m.cells[0].x, m.cells[0].y).show(len(m.cells), link.level)
+
+		publish(Event{
+			Type:       EventMatch,
+			X:          m.cells[0].x,
+			Y:          m.cells[0].y,
+			ComboLevel: len(m.cells),
+			ChainLevel: link.level,
+		})
 	}
 
 	for _, link := range dirtyLinks {
@@ -386,6 +421,35 @@ func (b *Board) addNewMatches() {
 	}
 }
 
+// updateDanger publishes EventDanger or EventSafe when blocks enter or leave the top rings.
+func (b *Board) updateDanger() {
+	n := dangerRingCount
+	if n > len(b.Rings) {
+		n = len(b.Rings)
+	}
+
+	danger := false
+loop:
+	for _, r := range b.Rings[:n] {
+		for _, c := range r.Cells {
+			if c.Block.State != BlockCleared {
+				danger = true
+				break loop
+			}
+		}
+	}
+
+	if danger == b.danger {
+		return
+	}
+	b.danger = danger
+	if danger {
+		publish(Event{Type: EventDanger})
+	} else {
+		publish(Event{Type: EventSafe})
+	}
+}
+
 func (b *Board) updateMatches() {
 	// Update each match - clearing one block at a time.
 	for i := 0; i < len(b.matches); i++ {
@@ -394,12 +458,19 @@ func (b *Board) updateMatches() {
 
 	loop:
 		// Animate each block one at a time. Break if it is still animating.
-		for _, mc := range m.cells {
+		for j, mc := range m.cells {
 			block := b.blockAt(mc.x, mc.y)
 			switch {
 			case block.State == BlockCracked:
 				block.State = BlockExploding
-				audio.Play(audio.SoundClear)
+				publish(Event{
+					Type:       EventClear,
+					X:          mc.x,
+					Y:          mc.y,
+					ComboLevel: len(m.cells),
+					ChainLevel: m.chainLevel,
+					ClearIndex: j,
+				})
 				finished = false
 				break loop
 
@@ -412,7 +483,9 @@ func (b *Board) updateMatches() {
 		// Clear the blocks and remove the chain once all animations are done.
 		if finished {
 			for _, mc := range m.cells {
-				b.blockAt(mc.x, mc.y).State = BlockClearPausing
+				block := b.blockAt(mc.x, mc.y)
+				block.State = BlockClearPausing
+				block.Kind = BlockNormal
 			}
 			b.matches = append(b.matches[:i], b.matches[i+1:]...)
 			i--
@@ -420,6 +493,26 @@ func (b *Board) updateMatches() {
 	}
 }
 
+// unlockAdjacentBlocks turns any locked blocks next to the match into normal blocks.
+func (b *Board) unlockAdjacentBlocks(m *match) {
+	unlock := func(x, y int) {
+		if y < 0 || y >= len(b.Rings) {
+			return
+		}
+		x = (x + b.CellCount) % b.CellCount
+		if block := b.blockAt(x, y); block.Kind == BlockLocked && block.State == BlockStatic {
+			block.Kind = BlockNormal
+		}
+	}
+
+	for _, mc := range m.cells {
+		unlock(mc.x-1, mc.y)
+		unlock(mc.x+1, mc.y)
+		unlock(mc.x, mc.y-1)
+		unlock(mc.x, mc.y+1)
+	}
+}
+
 func (b *Board) StateProgress(fudge float32) float32 {
 	totalSteps := boardStateSteps[b.State]
 	if totalSteps == 0 {
@@ -53,3 +53,67 @@ func TestDropBlocks(t *testing.T) {
 		}
 	}
 }
+
+func TestUnlockAdjacentBlocks(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		board *Board
+		match *match
+		want  []BlockKind
+	}{
+		{
+			desc: "unlock left and wrapped right",
+			board: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Kind: BlockLocked}},
+							{Block: &Block{Kind: BlockLocked}},
+							{Block: &Block{}},
+							{Block: &Block{Kind: BlockLocked}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 4,
+			},
+			match: &match{
+				cells: []*matchCell{
+					{3, 0},
+				},
+			},
+			want: []BlockKind{BlockNormal, BlockLocked, BlockNormal, BlockLocked},
+		},
+		{
+			desc: "ignore matched locked block",
+			board: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Kind: BlockLocked, State: BlockFlashing}},
+							{Block: &Block{}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 2,
+			},
+			match: &match{
+				cells: []*matchCell{
+					{1, 0},
+				},
+			},
+			want: []BlockKind{BlockLocked, BlockNormal},
+		},
+	} {
+		tt.board.unlockAdjacentBlocks(tt.match)
+
+		var got []BlockKind
+		for _, c := range tt.board.Rings[0].Cells {
+			got = append(got, c.Block.Kind)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] board.unlockAdjacentBlocks(%s) -> %v, want %v", tt.desc, pp(tt.match), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package game
+
+// Event is something that happened in the game that other packages can react to.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+
+	// X is the column of the cell where the event happened on the board, if any.
+	// It is the column the selector moves to for EventMove and the selector's column for EventNewBoard.
+	X int
+
+	// Y is the row of the cell where the event happened on the board, if any.
+	// It is the row the selector moves to for EventMove and the selector's row for EventNewBoard.
+	Y int
+
+	// CellCount is the number of cells in each ring for EventNewBoard.
+	CellCount int
+
+	// ComboLevel is the number of blocks in the match for EventMatch and EventClear.
+	ComboLevel int
+
+	// ChainLevel is the chain level of the match for EventMatch and EventClear.
+	ChainLevel int
+
+	// ClearIndex is the index of the block within its match for EventClear.
+	// Blocks in a match clear one at a time so it rises by one with each block.
+	ClearIndex int
+
+	// Speed is the board's new speed for EventNewBoard and EventSpeedUp.
+	Speed int
+
+	// State is the game's new state for EventStateChange.
+	State GameState
+
+	// Theme is the ID of the theme the player picked for EventThemeChange.
+	Theme string
+
+	// Tick is the number of game updates before the event happened.
+	// Events that happen during the same update or between the same updates have the same tick.
+	Tick int
+}
+
+//go:generate stringer -type=EventType
+type EventType int32
+
+const (
+	// EventMove is when the selector moves to another cell.
+	EventMove EventType = iota
+
+	// EventSwap is when the player swaps blocks.
+	EventSwap
+
+	// EventMatch is when a new match is found.
+	EventMatch
+
+	// EventClear is when a block in a match starts exploding.
+	EventClear
+
+	// EventLand is when a dropped block lands.
+	EventLand
+
+	// EventSpeedUp is when the board's speed increases.
+	EventSpeedUp
+
+	// EventGameOver is when the blocks reach the top of the board.
+	EventGameOver
+
+	// EventPause is when the player pauses the game.
+	EventPause
+
+	// EventContinue is when the player continues a paused game.
+	EventContinue
+
+	// EventMenuMove is when the player moves the menu focus or changes a menu value.
+	EventMenuMove
+
+	// EventMenuSelect is when the player selects a menu item.
+	EventMenuSelect
+
+	// EventStateChange is when the game changes to another GameState.
+	EventStateChange
+
+	// EventNewBoard is when a new board is created for a new game.
+	EventNewBoard
+
+	// EventDanger is when blocks stack up near the top of the board.
+	EventDanger
+
+	// EventSafe is when the blocks near the top of the board are cleared.
+	EventSafe
+
+	// EventThemeChange is when the player picks another theme to load assets from.
+	// A listener must set the theme with asset.SetTheme.
+	EventThemeChange
+)
+
+var (
+	// listeners are the functions called with each published event.
+	listeners []func(Event)
+
+	// tick is the number of game updates so far, which publish stamps on each event.
+	tick int
+)
+
+// Subscribe adds a function that is called with each event as it happens.
+// It is called on the same thread that calls KeyCallback and Update.
+func Subscribe(listener func(Event)) {
+	listeners = append(listeners, listener)
+}
+
+// publish calls each listener with the event stamped with the current tick.
+func publish(e Event) {
+	e.Tick = tick
+	for _, l := range listeners {
+		l(e)
+	}
+}
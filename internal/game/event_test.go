@@ -0,0 +1,299 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/asset"
+)
+
+func TestSwapEvents(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		board *Board
+		want  []Event
+	}{
+		{
+			desc: "swap visible blocks",
+			board: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Green}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 2,
+				Selector:  newSelector(1, 2),
+				State:     BoardLive,
+			},
+			want: []Event{
+				{Type: EventSwap},
+			},
+		},
+		{
+			desc: "no event for invisible blocks",
+			board: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{State: BlockCleared}},
+							{Block: &Block{State: BlockCleared}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 2,
+				Selector:  newSelector(1, 2),
+				State:     BoardLive,
+			},
+		},
+		{
+			desc: "no event for locked blocks",
+			board: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Kind: BlockLocked}},
+							{Block: &Block{Color: Green}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 2,
+				Selector:  newSelector(1, 2),
+				State:     BoardLive,
+			},
+		},
+	} {
+		var got []Event
+		listeners = []func(Event){func(e Event) {
+			got = append(got, e)
+		}}
+
+		tt.board.swap()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] board.swap() published %s, want %s", tt.desc, pp(got), pp(tt.want))
+		}
+	}
+	listeners = nil
+}
+
+func TestMoveEvents(t *testing.T) {
+	for _, tt := range []struct {
+		desc   string
+		startX int
+		move   func(s *Selector)
+		wantX  int
+		wantY  int
+	}{
+		{
+			desc:   "move up",
+			startX: 1,
+			move:   (*Selector).moveUp,
+			wantX:  1,
+			wantY:  0,
+		},
+		{
+			desc:   "move down",
+			startX: 1,
+			move:   (*Selector).moveDown,
+			wantX:  1,
+			wantY:  2,
+		},
+		{
+			desc:   "move left",
+			startX: 1,
+			move:   (*Selector).moveLeft,
+			wantX:  0,
+			wantY:  1,
+		},
+		{
+			desc:   "move right wraps around the ring",
+			startX: 2,
+			move:   (*Selector).moveRight,
+			wantX:  0,
+			wantY:  1,
+		},
+	} {
+		var got []Event
+		listeners = []func(Event){func(e Event) {
+			got = append(got, e)
+		}}
+
+		s := newSelector(3, 3)
+		s.X, s.Y = tt.startX, 1
+		tt.move(s)
+
+		// The event has the position that the selector has once the move completes.
+		want := []Event{{Type: EventMove, X: tt.wantX, Y: tt.wantY}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("[%s] move published %s, want %s", tt.desc, pp(got), pp(want))
+		}
+		for s.State != SelectorStatic {
+			s.update()
+		}
+		if s.X != tt.wantX || s.Y != tt.wantY {
+			t.Errorf("[%s] selector moved to %d, %d, want %d, %d", tt.desc, s.X, s.Y, tt.wantX, tt.wantY)
+		}
+	}
+	listeners = nil
+}
+
+func TestEventTicks(t *testing.T) {
+	var got []Event
+	listeners = []func(Event){func(e Event) {
+		got = append(got, e)
+	}}
+	tick = 0
+	defer func() {
+		listeners = nil
+		tick = 0
+	}()
+
+	g := &Game{Menu: mainMenu}
+	g.setState(GameInitial)
+	g.Update()
+	g.Update()
+	g.setState(GamePaused)
+	g.setState(GameInitial)
+
+	// Events between the same updates have the same tick.
+	want := []Event{
+		{Type: EventStateChange, State: GameInitial},
+		{Type: EventStateChange, State: GamePaused, Tick: 2},
+		{Type: EventStateChange, State: GameInitial, Tick: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("published %s, want %s", pp(got), pp(want))
+	}
+}
+
+func TestClearEvents(t *testing.T) {
+	var got []Event
+	listeners = []func(Event){func(e Event) {
+		got = append(got, e)
+	}}
+	defer func() {
+		listeners = nil
+	}()
+
+	b := &Board{
+		Rings: []*Ring{
+			{
+				Cells: []*Cell{
+					{Block: &Block{State: BlockExploded}},
+					{Block: &Block{State: BlockCracked}},
+					{Block: &Block{State: BlockCracked}},
+				},
+			},
+		},
+		RingCount: 1,
+		CellCount: 3,
+		matches: []*match{
+			{
+				cells: []*matchCell{
+					{0, 0},
+					{1, 0},
+					{2, 0},
+				},
+				chainLevel: 2,
+			},
+		},
+	}
+
+	b.updateMatches()
+
+	want := []Event{
+		{Type: EventClear, X: 1, ComboLevel: 3, ChainLevel: 2, ClearIndex: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("board.updateMatches() published %s, want %s", pp(got), pp(want))
+	}
+}
+
+func TestDangerEvents(t *testing.T) {
+	var got []Event
+	listeners = []func(Event){func(e Event) {
+		got = append(got, e)
+	}}
+	defer func() {
+		listeners = nil
+	}()
+
+	cleared := func() *Ring {
+		return &Ring{Cells: []*Cell{{Block: &Block{State: BlockCleared}}}}
+	}
+	b := &Board{
+		Rings:     []*Ring{cleared(), cleared(), cleared()},
+		RingCount: 3,
+		CellCount: 1,
+	}
+
+	b.updateDanger()
+	b.Rings[1].Cells[0].Block.State = BlockStatic
+	b.updateDanger()
+	b.updateDanger()
+	b.Rings[1].Cells[0].Block.State = BlockCleared
+	b.updateDanger()
+
+	want := []Event{
+		{Type: EventDanger},
+		{Type: EventSafe},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("board.updateDanger() published %s, want %s", pp(got), pp(want))
+	}
+}
+
+func TestThemeChangeEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themes")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "neon"), 0755); err != nil {
+		t.Fatalf("os.Mkdir: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "neon", "theme.txt"), []byte("name = Neon\n"), 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile: %v", err)
+	}
+	if err := asset.InitThemes(dir, nil); err != nil {
+		t.Fatalf("asset.InitThemes: %v", err)
+	}
+	defer asset.InitThemes("", nil)
+
+	var got []Event
+	listeners = []func(Event){func(e Event) {
+		got = append(got, e)
+	}}
+	defer func() {
+		listeners = nil
+	}()
+
+	g := &Game{Menu: optionsMenu}
+	g.Menu.reset()
+	g.Menu.FocusedIndex = 1
+	updateThemeItem()
+	themeItem.Selector.selectedIndex = 1
+	g.changeOption()
+
+	want := []Event{{Type: EventThemeChange, Theme: "neon"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changeOption() published %s, want %s", pp(got), pp(want))
+	}
+
+	// The game leaves setting the theme to its listeners.
+	if got, want := asset.CurrentTheme().ID, asset.DefaultTheme; got != want {
+		t.Errorf("changeOption() set the theme to %q, want %q", got, want)
+	}
+	if got, want := themeItem.Selector.selectedIndex, 0; got != want {
+		t.Errorf("changeOption() selected theme %d, want %d until a listener sets the theme", got, want)
+	}
+}
@@ -1,10 +1,13 @@
 package game
 
 import (
-	"github.com/btmura/blockcillin/internal/audio"
-	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/btmura/blockcillin/internal/asset"
+	"github.com/btmura/blockcillin/internal/locale"
 )
 
+// defaultThemeKey is the message key of the built-in theme's name.
+const defaultThemeKey = "theme.default"
+
 const (
 	updatesPerSec = 60
 	SecPerUpdate  = 1.0 / updatesPerSec
@@ -43,15 +46,18 @@ var gameStateSteps = [...]float32{
 }
 
 func New() *Game {
-	return &Game{
+	g := &Game{
 		Menu: mainMenu,
 	}
+	g.setState(GameInitial)
+	return g
 }
 
-func (g *Game) KeyCallback(key glfw.Key, action glfw.Action) {
-	if action != glfw.Press && action != glfw.Repeat {
-		// Handle any release triggers.
-		if key == glfw.KeyLeftAlt {
+func (g *Game) KeyCallback(key Key, action KeyAction) {
+	if action != KeyPress && action != KeyRepeat {
+		// Handle any release triggers. There is no board to stop raising if the key
+		// was let go in a menu before any game was started.
+		if key == KeyRaise && g.Board != nil {
 			g.Board.useManualRiseRate = false
 		}
 		return
@@ -64,52 +70,70 @@ func (g *Game) KeyCallback(key glfw.Key, action glfw.Action) {
 	switch g.State {
 	case GamePlaying:
 		switch key {
-		case glfw.KeyLeft:
+		case KeyLeft:
 			g.Board.moveLeft()
 
-		case glfw.KeyRight:
+		case KeyRight:
 			g.Board.moveRight()
 
-		case glfw.KeyDown:
+		case KeyDown:
 			g.Board.moveDown()
 
-		case glfw.KeyUp:
+		case KeyUp:
 			g.Board.moveUp()
 
-		case glfw.KeySpace:
+		case KeySpace:
 			g.Board.swap()
 
-		case glfw.KeyLeftAlt:
+		case KeyRaise:
 			g.Board.useManualRiseRate = true
 
-		case glfw.KeyEscape:
+		case KeyEscape:
 			g.setState(GamePaused)
 			g.Menu = pausedMenu
 			g.Menu.reset()
-			audio.Play(audio.SoundSelect)
+			publish(Event{Type: EventPause})
 		}
 
 	case GameInitial, GamePaused:
 		switch key {
-		case glfw.KeyLeft:
+		case KeyLeft:
 			g.Menu.moveLeft()
+			g.changeOption()
 
-		case glfw.KeyRight:
+		case KeyRight:
 			g.Menu.moveRight()
+			g.changeOption()
 
-		case glfw.KeyDown:
+		case KeyDown:
 			g.Menu.moveDown()
 
-		case glfw.KeyUp:
+		case KeyUp:
 			g.Menu.moveUp()
 
-		case glfw.KeyEnter, glfw.KeySpace:
+		case KeyEnter, KeySpace:
 			switch g.Menu.focused() {
 			case MenuNewGameItem:
 				g.Menu.selectItem()
 				g.Menu = newGameMenu
 				g.Menu.reset()
 
+			case MenuOptionsItem:
+				g.Menu.selectItem()
+				g.Menu = optionsMenu
+				g.Menu.reset()
+				updateLanguageItem()
+				updateThemeItem()
+
+			case MenuBack:
+				g.Menu.selectItem()
+				if g.State == GamePaused {
+					g.Menu = pausedMenu
+				} else {
+					g.Menu = mainMenu
+				}
+				g.Menu.reset()
+
 			case MenuExit:
 				g.Menu.selectItem()
 				g.setState(GameExiting)
@@ -131,8 +155,9 @@ func (g *Game) KeyCallback(key glfw.Key, action glfw.Action) {
 				}
 
 				speed := speedItem.Slider.Value
+				specialBlocks := blocksItem.Selector.Value() == MenuVariety
 
-				b := newBoard(numBlockColors, speed)
+				b := newBoard(numBlockColors, speed, specialBlocks)
 				h := newHUD(speed)
 				if g.Board == nil {
 					g.Board = b
@@ -153,18 +178,63 @@ func (g *Game) KeyCallback(key glfw.Key, action glfw.Action) {
 				g.Menu.reset()
 			}
 
-		case glfw.KeyEscape:
+		case KeyEscape:
 			switch g.State {
 			case GamePaused:
 				g.setState(GamePlaying)
-				audio.Play(audio.SoundSelect)
+				publish(Event{Type: EventContinue})
 			}
 		}
 	}
 }
 
+// changeOption applies the focused option after its value is changed.
+func (g *Game) changeOption() {
+	switch g.Menu.focused() {
+	case MenuLanguage:
+		locale.SetLanguage(locale.Languages()[languageItem.Selector.selectedIndex].ID)
+		// Show the default theme's name in the new language.
+		updateThemeItem()
+
+	case MenuTheme:
+		publish(Event{Type: EventThemeChange, Theme: asset.Themes()[themeItem.Selector.selectedIndex].ID})
+		// Show the names in the new theme's catalogs.
+		updateLanguageItem()
+		updateThemeItem()
+	}
+}
+
+// updateLanguageItem fills the language item with the languages and selects the current one.
+func updateLanguageItem() {
+	s := languageItem.Selector
+	s.Names = nil
+	for i, l := range locale.Languages() {
+		s.Names = append(s.Names, l.Name())
+		if l == locale.Current() {
+			s.selectedIndex = i
+		}
+	}
+}
+
+// updateThemeItem fills the theme item with the themes and selects the current one.
+func updateThemeItem() {
+	s := themeItem.Selector
+	s.Names = nil
+	for i, t := range asset.Themes() {
+		name := t.Name
+		if t.ID == asset.DefaultTheme {
+			name = locale.Text(defaultThemeKey)
+		}
+		s.Names = append(s.Names, name)
+		if t == asset.CurrentTheme() {
+			s.selectedIndex = i
+		}
+	}
+}
+
 func (g *Game) Update() {
 	g.GlobalPulse++
+	tick++
 
 	switch g.State {
 	case GameInitial, GamePaused, GameExiting:
@@ -213,6 +283,7 @@ func (g *Game) StateProgress(fudge float32) float32 {
 func (g *Game) setState(state GameState) {
 	g.State = state
 	g.step = 0
+	publish(Event{Type: EventStateChange, State: state})
 }
 
 func (g *Game) Done() bool {
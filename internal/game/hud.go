@@ -1,5 +1,7 @@
 package game
 
+import "github.com/btmura/blockcillin/internal/locale"
+
 type HUD struct {
 	Speed   int
 	TimeSec int
@@ -23,10 +25,16 @@ const (
 	HUDItemScore
 )
 
-var HUDItemText = [...]string{
-	HUDItemSpeed: "S P E E D",
-	HUDItemTime:  "T I M E",
-	HUDItemScore: "S C O R E",
+// hudItemKeys maps HUD items to the message keys of their labels.
+var hudItemKeys = [...]string{
+	HUDItemSpeed: "hud.speed",
+	HUDItemTime:  "hud.time",
+	HUDItemScore: "hud.score",
+}
+
+// Text returns the item's label in the current language.
+func (i HUDItem) Text() string {
+	return locale.Text(hudItemKeys[i])
 }
 
 func (h *HUD) update() {
@@ -0,0 +1,31 @@
+package game
+
+// Key is a key that the game responds to. Frontends map their own keys to these.
+type Key int
+
+const (
+	KeyLeft Key = iota
+	KeyRight
+	KeyDown
+	KeyUp
+	KeySpace
+	KeyEnter
+	KeyEscape
+
+	// KeyRaise raises the rings faster while it is held down.
+	KeyRaise
+)
+
+// KeyAction is what happened to a key.
+type KeyAction int
+
+const (
+	// KeyPress is when a key is pressed down.
+	KeyPress KeyAction = iota
+
+	// KeyRepeat is when a held down key repeats.
+	KeyRepeat
+
+	// KeyRelease is when a key is let go.
+	KeyRelease
+)
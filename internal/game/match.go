@@ -7,8 +7,12 @@ type match struct {
 	cells []*matchCell
 
 	// color is the color of the matching cells used internally by the matching algorithm.
-	// It is not accurate, because findGroupedMatches may combine matches of different color.
+	// It is not accurate, because findGroupedMatches may combine matches of different color
+	// and rainbow blocks may join matches of different colors.
 	color BlockColor
+
+	// chainLevel is the chain level assigned to the match when it was added to the board.
+	chainLevel int
 }
 
 type matchCell struct {
@@ -135,6 +139,13 @@ func findMatches(b *Board) []*match {
 		matches = append(matches, m)
 	}
 
+	// Expand any matches with bomb blocks to include their rings.
+	for _, m := range matches {
+		if expandBombs(b, m, matches) {
+			sort.Stable(byRowAndIndex(m.cells))
+		}
+	}
+
 	return matches
 }
 
@@ -142,71 +153,42 @@ func findHorizontalMatches(b *Board) []*match {
 	var matches []*match
 
 	for y, r := range b.Rings {
-		// Find the initial position where the colors change
+		// Find the initial position where the chain must break
 		// to handle a matching chain that wraps around.
 
-		var bc BlockColor
-		var initX int
-	init:
-		for x, c := range r.Cells {
-			switch {
-			case x == 0:
-				bc = c.Block.Color
-
-			case bc != c.Block.Color:
-				break init
+		initX := -1
+		for x := 1; x < b.CellCount; x++ {
+			if breaksChain(r.Cells[x-1].Block, r.Cells[x].Block) {
+				initX = x
+				break
 			}
-			initX++
 		}
 
-		// Now find matching matches starting from the initial position
-		// which may require wrapping around.
-
-		var startX int
-		var numMatches int
-
-		startChain := func(x int, c *Cell) {
-			bc = c.Block.Color
-			startX = x
-			numMatches = 1
+		// Rainbow blocks between two colors can join the chain on either side,
+		// so find where a chain really starts if no two neighbors break the chain.
+		if initX < 0 {
+			initX = ringStart(b.CellCount, func(i int) *Block {
+				return r.Cells[i].Block
+			})
 		}
 
-		continueChain := func() {
-			numMatches++
-		}
+		// Now find matching chains starting from the initial position
+		// which may require wrapping around.
 
-		endChain := func() {
-			if numMatches >= 3 {
-				m := &match{color: bc}
-				for i := 0; i < numMatches; i++ {
-					x := (startX + i) % b.CellCount
-					m.cells = append(m.cells, &matchCell{x, y})
-				}
-				matches = append(matches, m)
-			}
-			numMatches = 0
+		blockAt := func(i int) *Block {
+			return r.Cells[(initX+i)%b.CellCount].Block
 		}
 
-		for i := 0; i < b.CellCount; i++ {
-			x := (initX + i) % b.CellCount
-			c := r.Cells[x]
-			switch {
-			case c.Block.State != BlockStatic:
-				endChain()
-
-			case numMatches == 0:
-				startChain(x, c)
-
-			case bc == c.Block.Color:
-				continueChain()
-
-			case bc != c.Block.Color:
-				endChain()
-				startChain(x, c)
+		addMatch := func(start, count int, color BlockColor) {
+			m := &match{color: color}
+			for i := 0; i < count; i++ {
+				x := (initX + start + i) % b.CellCount
+				m.cells = append(m.cells, &matchCell{x, y})
 			}
+			matches = append(matches, m)
 		}
 
-		endChain()
+		findChains(b.CellCount, blockAt, addMatch)
 	}
 
 	return matches
@@ -216,60 +198,163 @@ func findVerticalMatches(b *Board) []*match {
 	var matches []*match
 
 	for x := 0; x < b.CellCount; x++ {
-		var bc BlockColor
-		var startY int
-		var numMatches int
-
-		startChain := func(y int, c *Cell) {
-			bc = c.Block.Color
-			startY = y
-			numMatches = 1
+		blockAt := func(i int) *Block {
+			return b.Rings[i].Cells[x].Block
 		}
 
-		continueChain := func() {
-			numMatches++
+		addMatch := func(start, count int, color BlockColor) {
+			m := &match{color: color}
+			for i := 0; i < count; i++ {
+				y := start + i
+				m.cells = append(m.cells, &matchCell{x, y})
+			}
+			matches = append(matches, m)
 		}
 
-		endChain := func() {
-			if numMatches >= 3 {
-				m := &match{color: bc}
-				for i := 0; i < numMatches; i++ {
-					y := startY + i
-					m.cells = append(m.cells, &matchCell{x, y})
-				}
-				matches = append(matches, m)
-			}
-			numMatches = 0
+		findChains(len(b.Rings), blockAt, addMatch)
+	}
+	return matches
+}
+
+// findChains finds chains of at least 3 matching blocks within a line of blocks.
+// It calls addMatch with the starting position, length, and color of each chain.
+func findChains(numBlocks int, blockAt func(i int) *Block, addMatch func(start, count int, color BlockColor)) {
+	var color BlockColor
+	var hasColor bool
+	var startPos int
+	var numMatches int
+
+	// numRainbows is the number of rainbow blocks at the end of the current chain.
+	// They can start the next chain if the current chain is too short to match.
+	var numRainbows int
+
+	startChain := func(pos, numLeadingRainbows int, b *Block) {
+		startPos = pos
+		numMatches = numLeadingRainbows + 1
+		if b.Kind == BlockRainbow {
+			color, hasColor = 0, false
+			numRainbows = numLeadingRainbows + 1
+		} else {
+			color, hasColor = b.Color, true
+			numRainbows = 0
+		}
+	}
+
+	continueChain := func(b *Block) {
+		numMatches++
+		switch {
+		case b.Kind == BlockRainbow:
+			numRainbows++
+
+		default:
+			color, hasColor = b.Color, true
+			numRainbows = 0
 		}
+	}
+
+	endChain := func() bool {
+		matched := numMatches >= 3
+		if matched {
+			addMatch(startPos, numMatches, color)
+		}
+		numMatches = 0
+		return matched
+	}
 
-		for y, r := range b.Rings {
-			c := r.Cells[x]
-			switch {
-			case c.Block.State != BlockStatic:
-				endChain()
+	for i := 0; i < numBlocks; i++ {
+		b := blockAt(i)
+		switch {
+		case !matchable(b):
+			endChain()
 
-			case numMatches == 0:
-				startChain(y, c)
+		case numMatches == 0:
+			startChain(i, 0, b)
 
-			case bc == c.Block.Color:
-				continueChain()
+		case b.Kind == BlockRainbow, !hasColor, color == b.Color:
+			continueChain(b)
 
-			case bc != c.Block.Color:
-				endChain()
-				startChain(y, c)
+		default:
+			n := numRainbows
+			if endChain() {
+				n = 0
 			}
+			startChain(i-n, n, b)
 		}
-
-		endChain()
 	}
-	return matches
+
+	endChain()
 }
 
-func intersects(m1, m2 *match) bool {
-	if m1.color != m2.color {
+// matchable returns whether the block can be part of a match.
+func matchable(b *Block) bool {
+	return b.State == BlockStatic && b.Kind != BlockLocked
+}
+
+// breaksChain returns whether two adjacent blocks can never be part of the same chain.
+func breaksChain(b1, b2 *Block) bool {
+	if !matchable(b1) || !matchable(b2) {
+		return true
+	}
+	if b1.Kind == BlockRainbow || b2.Kind == BlockRainbow {
 		return false
 	}
+	return b1.Color != b2.Color
+}
+
+// ringStart returns the position in a ring of blocks to start finding chains from,
+// so that no chain that wraps around is split. Whether rainbow blocks join the chain
+// before or after them depends on the blocks before them, so it goes around the ring
+// more than once and returns where a chain starts on the second time around.
+// It returns 0 if no chain starts there, like when the whole ring is one chain.
+func ringStart(numBlocks int, blockAt func(i int) *Block) int {
+	start := 0
+	found := false
+	findChains(3*numBlocks, func(i int) *Block {
+		return blockAt(i % numBlocks)
+	}, func(pos, count int, color BlockColor) {
+		if !found && pos >= numBlocks && pos < 2*numBlocks {
+			start, found = pos-numBlocks, true
+		}
+	})
+	return start
+}
+
+// expandBombs adds the cells of any rings with matched bomb blocks to the match.
+// Cells that are already part of the given matches are not added again.
+func expandBombs(b *Board, m *match, matches []*match) bool {
+	expanded := false
+	explodedRings := map[int]bool{}
+
+	// Range over the original cells. The cells added are all in rings that already exploded,
+	// so any bombs among them have nothing more to add.
+	for _, mc := range m.cells {
+		if b.blockAt(mc.x, mc.y).Kind != BlockBomb || explodedRings[mc.y] {
+			continue
+		}
+		explodedRings[mc.y] = true
+
+	loop:
+		for x, c := range b.Rings[mc.y].Cells {
+			if c.Block.State != BlockStatic {
+				continue
+			}
+			rc := &matchCell{x, mc.y}
+			for _, om := range matches {
+				if contains(om, rc) {
+					continue loop
+				}
+			}
+			m.cells = append(m.cells, rc)
+			expanded = true
+		}
+	}
 
+	return expanded
+}
+
+// intersects returns whether the matches share any cells.
+// Matches of different colors can share cells with rainbow blocks.
+func intersects(m1, m2 *match) bool {
 	for _, c1 := range m1.cells {
 		for _, c2 := range m2.cells {
 			if c1.x == c2.x && c1.y == c2.y {
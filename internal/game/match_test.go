@@ -193,6 +193,37 @@ func TestFindMatches(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "bomb clears ring",
+			input: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Red, Kind: BlockBomb}},
+							{Block: &Block{Color: Green, Kind: BlockLocked}},
+							{Block: &Block{Color: Blue}},
+							{Block: &Block{Color: Blue, State: BlockCleared}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 6,
+			},
+			want: []*match{
+				{
+					color: Red,
+					cells: []*matchCell{
+						{0, 0},
+						{1, 0},
+						{2, 0},
+						{3, 0},
+						{4, 0},
+					},
+				},
+			},
+		},
 	} {
 		got := findMatches(tt.input)
 		if !reflect.DeepEqual(got, tt.want) {
@@ -448,6 +479,158 @@ func TestFindHorizontalMatches(t *testing.T) {
 				CellCount: 5,
 			},
 		},
+		{
+			desc: "rainbow matches any color",
+			input: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Color: Green}},
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Kind: BlockRainbow}},
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Blue}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 5,
+			},
+			want: []*match{
+				{
+					color: Red,
+					cells: []*matchCell{
+						{1, 0},
+						{2, 0},
+						{3, 0},
+					},
+				},
+			},
+		},
+		{
+			desc: "rainbow starts next chain",
+			input: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Color: Green}},
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Kind: BlockRainbow}},
+							{Block: &Block{Color: Blue}},
+							{Block: &Block{Color: Blue}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 5,
+			},
+			want: []*match{
+				{
+					color: Blue,
+					cells: []*matchCell{
+						{2, 0},
+						{3, 0},
+						{4, 0},
+					},
+				},
+			},
+		},
+		{
+			desc: "rainbow chain wraps around",
+			input: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Kind: BlockRainbow}},
+							{Block: &Block{Color: Green}},
+							{Block: &Block{Color: Green}},
+							{Block: &Block{Color: Green}},
+							{Block: &Block{Kind: BlockRainbow}},
+							{Block: &Block{Color: Red}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 8,
+			},
+			want: []*match{
+				{
+					color: Green,
+					cells: []*matchCell{
+						{3, 0},
+						{4, 0},
+						{5, 0},
+						{6, 0},
+					},
+				},
+				{
+					color: Red,
+					cells: []*matchCell{
+						{7, 0},
+						{0, 0},
+						{1, 0},
+						{2, 0},
+					},
+				},
+			},
+		},
+		{
+			desc: "rainbow starts chain that wraps around",
+			input: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Kind: BlockRainbow}},
+							{Block: &Block{Color: Green}},
+							{Block: &Block{Color: Green}},
+							{Block: &Block{Kind: BlockRainbow}},
+							{Block: &Block{Color: Red}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 6,
+			},
+			want: []*match{
+				{
+					color: Green,
+					cells: []*matchCell{
+						{2, 0},
+						{3, 0},
+						{4, 0},
+					},
+				},
+				{
+					color: Red,
+					cells: []*matchCell{
+						{5, 0},
+						{0, 0},
+						{1, 0},
+					},
+				},
+			},
+		},
+		{
+			desc: "no match due to locked block",
+			input: &Board{
+				Rings: []*Ring{
+					{
+						Cells: []*Cell{
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Red, Kind: BlockLocked}},
+							{Block: &Block{Color: Red}},
+							{Block: &Block{Color: Green}},
+						},
+					},
+				},
+				RingCount: 1,
+				CellCount: 5,
+			},
+		},
 	} {
 		got := findHorizontalMatches(tt.input)
 		if !reflect.DeepEqual(got, tt.want) {
@@ -653,6 +836,28 @@ func TestFindVerticalMatches(t *testing.T) {
 				CellCount: 1,
 			},
 		},
+		{
+			desc: "rainbow matches any color",
+			input: &Board{
+				Rings: []*Ring{
+					{Cells: []*Cell{{Block: &Block{Kind: BlockRainbow}}}},
+					{Cells: []*Cell{{Block: &Block{Color: Green}}}},
+					{Cells: []*Cell{{Block: &Block{Color: Green}}}},
+				},
+				RingCount: 3,
+				CellCount: 1,
+			},
+			want: []*match{
+				{
+					color: Green,
+					cells: []*matchCell{
+						{0, 0},
+						{0, 1},
+						{0, 2},
+					},
+				},
+			},
+		},
 	} {
 		got := findVerticalMatches(tt.input)
 		if !reflect.DeepEqual(got, tt.want) {
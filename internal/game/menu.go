@@ -1,6 +1,6 @@
 package game
 
-import "github.com/btmura/blockcillin/internal/audio"
+import "github.com/btmura/blockcillin/internal/locale"
 
 type Menu struct {
 	ID           MenuID
@@ -17,13 +17,21 @@ const (
 	MenuNewGame
 	MenuPaused
 	MenuGameOver
+	MenuOptions
 )
 
-var MenuTitleText = map[MenuID]string{
-	MenuMain:     "b l o c k c i l l i n",
-	MenuNewGame:  "N E W  G A M E",
-	MenuPaused:   "P A U S E D",
-	MenuGameOver: "G A M E  O V E R",
+// menuTitleKeys maps menu IDs to the message keys of their titles.
+var menuTitleKeys = map[MenuID]string{
+	MenuMain:     "menu_title.main",
+	MenuNewGame:  "menu_title.new_game",
+	MenuPaused:   "menu_title.paused",
+	MenuGameOver: "menu_title.game_over",
+	MenuOptions:  "menu_title.options",
+}
+
+// Text returns the menu's title in the current language.
+func (id MenuID) Text() string {
+	return locale.Text(menuTitleKeys[id])
 }
 
 type MenuItem struct {
@@ -38,31 +46,47 @@ type MenuItemID byte
 const (
 	MenuNewGameItem MenuItemID = iota
 	MenuStats
-	MenuOptions
+	MenuOptionsItem
 	MenuCredits
 	MenuExit
 
 	MenuSpeed
 	MenuDifficulty
+	MenuBlocks
 	MenuOK
 
 	MenuContinueGame
 	MenuQuit
-)
 
-var MenuItemText = map[MenuItemID]string{
-	MenuNewGameItem: "N E W  G A M E",
-	MenuStats:       "S T A T S",
-	MenuOptions:     "O P T I O N S",
-	MenuCredits:     "C R E D I T S",
-	MenuExit:        "E X I T",
+	MenuLanguage
+	MenuTheme
+	MenuBack
+)
 
-	MenuSpeed:      "S P E E D",
-	MenuDifficulty: "D I F F I C U L T Y",
-	MenuOK:         "O K",
+// menuItemKeys maps menu item IDs to the message keys of their text.
+var menuItemKeys = map[MenuItemID]string{
+	MenuNewGameItem: "menu_item.new_game",
+	MenuStats:       "menu_item.stats",
+	MenuOptionsItem: "menu_item.options",
+	MenuCredits:     "menu_item.credits",
+	MenuExit:        "menu_item.exit",
+
+	MenuSpeed:      "menu_item.speed",
+	MenuDifficulty: "menu_item.difficulty",
+	MenuBlocks:     "menu_item.blocks",
+	MenuOK:         "menu_item.ok",
+
+	MenuContinueGame: "menu_item.continue_game",
+	MenuQuit:         "menu_item.quit",
+
+	MenuLanguage: "menu_item.language",
+	MenuTheme:    "menu_item.theme",
+	MenuBack:     "menu_item.back",
+}
 
-	MenuContinueGame: "C O N T I N U E  G A M E",
-	MenuQuit:         "Q U I T",
+// Text returns the item's text in the current language.
+func (id MenuItemID) Text() string {
+	return locale.Text(menuItemKeys[id])
 }
 
 func (i *MenuItem) SingleChoice() bool {
@@ -70,7 +94,13 @@ func (i *MenuItem) SingleChoice() bool {
 }
 
 type MenuSelector struct {
-	Choices       []MenuChoiceID
+	// Choices are the IDs of the choices to pick from.
+	Choices []MenuChoiceID
+
+	// Names are the names of the choices to pick from if they are picked by name
+	// like languages and themes instead of by ID. Choices is empty when Names is set.
+	Names []string
+
 	selectedIndex int
 }
 
@@ -81,18 +111,46 @@ const (
 	MenuEasy MenuChoiceID = iota
 	MenuMedium
 	MenuHard
+
+	MenuClassic
+	MenuVariety
 )
 
-var MenuChoiceText = map[MenuChoiceID]string{
-	MenuEasy:   "E A S Y",
-	MenuMedium: "M E D I U M",
-	MenuHard:   "H A R D",
+// menuChoiceKeys maps menu choice IDs to the message keys of their text.
+var menuChoiceKeys = map[MenuChoiceID]string{
+	MenuEasy:   "menu_choice.easy",
+	MenuMedium: "menu_choice.medium",
+	MenuHard:   "menu_choice.hard",
+
+	MenuClassic: "menu_choice.classic",
+	MenuVariety: "menu_choice.variety",
+}
+
+// Text returns the choice's text in the current language.
+func (id MenuChoiceID) Text() string {
+	return locale.Text(menuChoiceKeys[id])
 }
 
 func (s *MenuSelector) Value() MenuChoiceID {
 	return s.Choices[s.selectedIndex]
 }
 
+// Text returns the selected choice's name or text in the current language.
+func (s *MenuSelector) Text() string {
+	if len(s.Names) > 0 {
+		return s.Names[s.selectedIndex]
+	}
+	return s.Value().Text()
+}
+
+// len returns how many choices there are to pick from.
+func (s *MenuSelector) len() int {
+	if len(s.Names) > 0 {
+		return len(s.Names)
+	}
+	return len(s.Choices)
+}
+
 type MenuSlider struct {
 	Min   int
 	Max   int
@@ -105,7 +163,7 @@ var (
 		Items: []*MenuItem{
 			{ID: MenuNewGameItem},
 			{ID: MenuStats},
-			{ID: MenuOptions},
+			{ID: MenuOptionsItem},
 			{ID: MenuCredits},
 			{ID: MenuExit},
 		},
@@ -131,11 +189,22 @@ var (
 		},
 	}
 
+	blocksItem = &MenuItem{
+		ID: MenuBlocks,
+		Selector: &MenuSelector{
+			Choices: []MenuChoiceID{
+				MenuClassic,
+				MenuVariety,
+			},
+		},
+	}
+
 	newGameMenu = &Menu{
 		ID: MenuNewGame,
 		Items: []*MenuItem{
 			speedItem,
 			difficultyItem,
+			blocksItem,
 			{ID: MenuOK},
 		},
 	}
@@ -144,11 +213,30 @@ var (
 		ID: MenuPaused,
 		Items: []*MenuItem{
 			{ID: MenuContinueGame},
-			{ID: MenuOptions},
+			{ID: MenuOptionsItem},
 			{ID: MenuQuit},
 		},
 	}
 
+	languageItem = &MenuItem{
+		ID:       MenuLanguage,
+		Selector: &MenuSelector{},
+	}
+
+	themeItem = &MenuItem{
+		ID:       MenuTheme,
+		Selector: &MenuSelector{},
+	}
+
+	optionsMenu = &Menu{
+		ID: MenuOptions,
+		Items: []*MenuItem{
+			languageItem,
+			themeItem,
+			{ID: MenuBack},
+		},
+	}
+
 	gameOverMenu = &Menu{
 		ID: MenuGameOver,
 		Items: []*MenuItem{
@@ -165,7 +253,7 @@ func (m *Menu) reset() {
 func (m *Menu) moveDown() {
 	m.FocusedIndex = (m.FocusedIndex + 1) % len(m.Items)
 	m.Selected = false
-	audio.Play(audio.SoundMove)
+	publish(Event{Type: EventMenuMove})
 }
 
 func (m *Menu) moveUp() {
@@ -173,7 +261,7 @@ func (m *Menu) moveUp() {
 		m.FocusedIndex = len(m.Items) - 1
 		m.Selected = false
 	}
-	audio.Play(audio.SoundMove)
+	publish(Event{Type: EventMenuMove})
 }
 
 func (m *Menu) moveLeft() {
@@ -181,15 +269,15 @@ func (m *Menu) moveLeft() {
 	switch {
 	case item.Selector != nil:
 		if item.Selector.selectedIndex--; item.Selector.selectedIndex < 0 {
-			item.Selector.selectedIndex = len(item.Selector.Choices) - 1
+			item.Selector.selectedIndex = item.Selector.len() - 1
 		}
-		audio.Play(audio.SoundMove)
+		publish(Event{Type: EventMenuMove})
 
 	case item.Slider != nil:
 		if item.Slider.Value--; item.Slider.Value < item.Slider.Min {
 			item.Slider.Value = item.Slider.Max
 		}
-		audio.Play(audio.SoundMove)
+		publish(Event{Type: EventMenuMove})
 	}
 }
 
@@ -197,14 +285,14 @@ func (m *Menu) moveRight() {
 	item := m.Items[m.FocusedIndex]
 	switch {
 	case item.Selector != nil:
-		item.Selector.selectedIndex = (item.Selector.selectedIndex + 1) % len(item.Selector.Choices)
-		audio.Play(audio.SoundMove)
+		item.Selector.selectedIndex = (item.Selector.selectedIndex + 1) % item.Selector.len()
+		publish(Event{Type: EventMenuMove})
 
 	case item.Slider != nil:
 		if item.Slider.Value++; item.Slider.Value > item.Slider.Max {
 			item.Slider.Value = item.Slider.Min
 		}
-		audio.Play(audio.SoundMove)
+		publish(Event{Type: EventMenuMove})
 	}
 }
 
@@ -214,5 +302,5 @@ func (m *Menu) focused() MenuItemID {
 
 func (m *Menu) selectItem() {
 	m.Selected = true
-	audio.Play(audio.SoundSelect)
+	publish(Event{Type: EventMenuSelect})
 }
@@ -4,9 +4,9 @@ package game
 
 import "fmt"
 
-const _MenuChoiceID_name = "MenuEasyMenuMediumMenuHard"
+const _MenuChoiceID_name = "MenuEasyMenuMediumMenuHardMenuClassicMenuVariety"
 
-var _MenuChoiceID_index = [...]uint8{0, 8, 18, 26}
+var _MenuChoiceID_index = [...]uint8{0, 8, 18, 26, 37, 48}
 
 func (i MenuChoiceID) String() string {
 	if i >= MenuChoiceID(len(_MenuChoiceID_index)-1) {
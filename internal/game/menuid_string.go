@@ -4,9 +4,9 @@ package game
 
 import "fmt"
 
-const _MenuID_name = "MenuMainMenuNewGameMenuPausedMenuGameOver"
+const _MenuID_name = "MenuMainMenuNewGameMenuPausedMenuGameOverMenuOptions"
 
-var _MenuID_index = [...]uint8{0, 8, 19, 29, 41}
+var _MenuID_index = [...]uint8{0, 8, 19, 29, 41, 52}
 
 func (i MenuID) String() string {
 	if i >= MenuID(len(_MenuID_index)-1) {
@@ -4,9 +4,9 @@ package game
 
 import "fmt"
 
-const _MenuItemID_name = "MenuNewGameItemMenuStatsMenuOptionsMenuCreditsMenuExitMenuSpeedMenuDifficultyMenuOKMenuContinueGameMenuQuit"
+const _MenuItemID_name = "MenuNewGameItemMenuStatsMenuOptionsItemMenuCreditsMenuExitMenuSpeedMenuDifficultyMenuBlocksMenuOKMenuContinueGameMenuQuitMenuLanguageMenuThemeMenuBack"
 
-var _MenuItemID_index = [...]uint8{0, 15, 24, 35, 46, 54, 63, 77, 83, 99, 107}
+var _MenuItemID_index = [...]uint8{0, 15, 24, 39, 50, 58, 67, 81, 91, 97, 113, 121, 133, 142, 150}
 
 func (i MenuItemID) String() string {
 	if i >= MenuItemID(len(_MenuItemID_index)-1) {
@@ -1,7 +1,5 @@
 package game
 
-import "github.com/btmura/blockcillin/internal/audio"
-
 type Selector struct {
 	// State is the state of the selector.
 	State SelectorState
@@ -55,31 +53,38 @@ func newSelector(ringCount, cellCount int) *Selector {
 func (s *Selector) moveUp() {
 	if s.State == SelectorStatic && s.Y > 0 {
 		s.setState(SelectorMovingUp)
-		audio.Play(audio.SoundMove)
+		s.publishMove()
 	}
 }
 
 func (s *Selector) moveDown() {
 	if s.State == SelectorStatic && s.Y < s.ringCount-1 {
 		s.setState(SelectorMovingDown)
-		audio.Play(audio.SoundMove)
+		s.publishMove()
 	}
 }
 
 func (s *Selector) moveLeft() {
 	if s.State == SelectorStatic {
 		s.setState(SelectorMovingLeft)
-		audio.Play(audio.SoundMove)
+		s.publishMove()
 	}
 }
 
 func (s *Selector) moveRight() {
 	if s.State == SelectorStatic {
 		s.setState(SelectorMovingRight)
-		audio.Play(audio.SoundMove)
+		s.publishMove()
 	}
 }
 
+// publishMove publishes EventMove with the position the selector is moving to,
+// since X and Y only change once the move animation completes.
+func (s *Selector) publishMove() {
+	x, y := s.nextPosition()
+	publish(Event{Type: EventMove, X: x, Y: y})
+}
+
 func (s *Selector) update() {
 	advance := func(nextState SelectorState) bool {
 		if s.step++; s.step >= selectorStateSteps[s.State] {
@@ -0,0 +1,196 @@
+package locale
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/btmura/blockcillin/internal/asset"
+)
+
+const (
+	// English is the ID of the language whose text is shown for keys that other languages leave out.
+	English = "en"
+
+	// catalogPrefix and catalogSuffix surround the language ID in the names of catalog assets.
+	catalogPrefix = "locale_"
+	catalogSuffix = ".txt"
+)
+
+// Keys of the messages that describe the language itself.
+const (
+	// NameKey is the key of the language's name in the language itself.
+	NameKey = "language.name"
+
+	// PlainFontKey and BoldFontKey are the keys of the names of the font assets
+	// that cover the language's script.
+	PlainFontKey = "font.plain"
+	BoldFontKey  = "font.bold"
+)
+
+// Language is a language that the game's text can be shown in.
+type Language struct {
+	// ID is the language's ID like "en" that names its catalog asset.
+	ID string
+
+	// messages maps message key to text in the language.
+	messages map[string]string
+}
+
+var (
+	// languages are the languages with catalogs sorted by ID.
+	languages []*Language
+
+	// english is the language that other languages fall back to.
+	english *Language
+
+	// current is the language that text is shown in.
+	current *Language
+)
+
+// Init loads the languages' catalogs from the assets and shows text in the given language.
+// The language can be an ID like "en" or a locale like "en_US.UTF-8" and falls back to English
+// if there is no catalog for it.
+func Init(lang string) error {
+	if err := load(); err != nil {
+		return err
+	}
+	current = english
+	SetLanguage(lang)
+	return nil
+}
+
+// Reload loads the languages' catalogs again from the current theme's assets and keeps
+// showing text in the current language. Call it after the current theme changes.
+// It keeps the old catalogs if the new ones cannot be loaded.
+func Reload() error {
+	id := English
+	if current != nil {
+		id = current.ID
+	}
+
+	oldLanguages, oldEnglish := languages, english
+	if err := load(); err != nil {
+		languages, english = oldLanguages, oldEnglish
+		return err
+	}
+
+	current = english
+	SetLanguage(id)
+	return nil
+}
+
+// load loads the languages' catalogs from the assets.
+func load() error {
+	// Sort the names so the languages are sorted by ID.
+	names := asset.AssetNames()
+	sort.Strings(names)
+
+	var langs []*Language
+	for _, name := range names {
+		if !strings.HasPrefix(name, catalogPrefix) || !strings.HasSuffix(name, catalogSuffix) {
+			continue
+		}
+
+		r, err := asset.Reader(name)
+		if err != nil {
+			return err
+		}
+
+		messages, err := decodeCatalog(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		langs = append(langs, &Language{
+			ID:       strings.TrimSuffix(strings.TrimPrefix(name, catalogPrefix), catalogSuffix),
+			messages: messages,
+		})
+	}
+
+	languages = langs
+	english = find(English)
+	if english == nil {
+		return fmt.Errorf("missing catalog: %s%s%s", catalogPrefix, English, catalogSuffix)
+	}
+	return nil
+}
+
+// Languages returns the languages with catalogs sorted by ID.
+func Languages() []*Language {
+	return languages
+}
+
+// Current returns the language that text is shown in.
+func Current() *Language {
+	return current
+}
+
+// SetLanguage shows text in the language with the ID or locale like "en_US.UTF-8".
+// It returns false and keeps the current language if there is no catalog for it.
+func SetLanguage(lang string) bool {
+	if i := strings.IndexAny(lang, "_.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if l := find(strings.ToLower(lang)); l != nil {
+		current = l
+		return true
+	}
+	return false
+}
+
+// Text returns the message's text in the current language, in English if the current
+// language leaves it out, or the key itself if no catalog has it.
+func Text(key string) string {
+	return current.Text(key)
+}
+
+// Name returns the language's name in the language itself.
+func (l *Language) Name() string {
+	if name, ok := l.messages[NameKey]; ok {
+		return name
+	}
+	return l.ID
+}
+
+// Text returns the message's text in the language, in English if the language
+// leaves it out, or the key itself if no catalog has it.
+func (l *Language) Text(key string) string {
+	if l != nil {
+		if text, ok := l.messages[key]; ok {
+			return text
+		}
+	}
+	if english != nil {
+		if text, ok := english.messages[key]; ok {
+			return text
+		}
+	}
+	return key
+}
+
+// find returns the language with the ID or nil if there is no catalog for it.
+func find(id string) *Language {
+	for _, l := range languages {
+		if l.ID == id {
+			return l
+		}
+	}
+	return nil
+}
+
+// decodeCatalog decodes a catalog with a "key = text" message on each line into a map of key to text.
+func decodeCatalog(r io.Reader) (map[string]string, error) {
+	entries, err := asset.DecodeEntries(r)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := map[string]string{}
+	for _, e := range entries {
+		messages[e.Key] = e.Value
+	}
+	return messages, nil
+}
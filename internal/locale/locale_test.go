@@ -0,0 +1,182 @@
+package locale
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/asset"
+)
+
+func TestDecodeCatalog(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			desc: "messages with comments and blank lines",
+			input: "# Menu titles.\n" +
+				"menu_title.main = b l o c k c i l l i n\n" +
+				"\n" +
+				"  hud.score=S C O R E  \n",
+			want: map[string]string{
+				"menu_title.main": "b l o c k c i l l i n",
+				"hud.score":       "S C O R E",
+			},
+		},
+		{
+			desc:  "empty text",
+			input: "key =\n",
+			want:  map[string]string{"key": ""},
+		},
+		{
+			desc:    "duplicate key",
+			input:   "key = a\nkey = b\n",
+			wantErr: true,
+		},
+	} {
+		got, gotErr := decodeCatalog(strings.NewReader(tt.input))
+		if (gotErr != nil) != tt.wantErr {
+			t.Errorf("[%s] decodeCatalog(%q) err = %v, want err %t", tt.desc, tt.input, gotErr, tt.wantErr)
+			continue
+		}
+		if gotErr == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] decodeCatalog(%q) = %v, want %v", tt.desc, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	en := &Language{ID: "en", messages: map[string]string{"a": "A", "b": "B"}}
+	es := &Language{ID: "es", messages: map[string]string{"a": "Á"}}
+	languages, english, current = []*Language{en, es}, en, en
+
+	for _, tt := range []struct {
+		desc string
+		lang string
+		key  string
+		want string
+	}{
+		{
+			desc: "text in the language",
+			lang: "es",
+			key:  "a",
+			want: "Á",
+		},
+		{
+			desc: "missing text falls back to English",
+			lang: "es",
+			key:  "b",
+			want: "B",
+		},
+		{
+			desc: "missing text in every language is the key",
+			lang: "es",
+			key:  "c",
+			want: "c",
+		},
+		{
+			desc: "locale with a territory and encoding",
+			lang: "es_MX.UTF-8",
+			key:  "a",
+			want: "Á",
+		},
+		{
+			desc: "unknown language keeps the current language",
+			lang: "xx",
+			key:  "a",
+			want: "A",
+		},
+	} {
+		current = en
+		SetLanguage(tt.lang)
+		if got := Text(tt.key); got != tt.want {
+			t.Errorf("[%s] SetLanguage(%q) Text(%q) = %q, want %q", tt.desc, tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	if err := Init("ru_RU.UTF-8"); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if got := Current().ID; got != "ru" {
+		t.Errorf("Current().ID = %q, want %q", got, "ru")
+	}
+
+	// Every language should name itself and have fonts, at least from English.
+	for _, l := range Languages() {
+		if l.Name() == l.ID {
+			t.Errorf("language %s has no %s", l.ID, NameKey)
+		}
+		for _, key := range []string{PlainFontKey, BoldFontKey} {
+			if l.Text(key) == key {
+				t.Errorf("language %s has no %s", l.ID, key)
+			}
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themes")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	themeDir := filepath.Join(dir, "test")
+	if err := os.MkdirAll(themeDir, 0755); err != nil {
+		t.Fatalf("os.MkdirAll: %v", err)
+	}
+	for name, content := range map[string]string{
+		"theme.txt": "name = Test\nlocale_ru.txt = ru.txt\n",
+		"ru.txt":    NameKey + " = Тест\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(themeDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("ioutil.WriteFile: %v", err)
+		}
+	}
+
+	if err := Init("ru"); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := asset.InitThemes(dir, nil); err != nil {
+		t.Fatalf("asset.InitThemes: %v", err)
+	}
+	defer asset.InitThemes("", nil)
+
+	for _, tt := range []struct {
+		desc  string
+		theme string
+		want  string
+	}{
+		{
+			desc:  "theme overrides the catalog",
+			theme: "test",
+			want:  "Тест",
+		},
+		{
+			desc:  "default theme restores the catalog",
+			theme: asset.DefaultTheme,
+			want:  "Русский",
+		},
+	} {
+		if !asset.SetTheme(tt.theme) {
+			t.Fatalf("[%s] asset.SetTheme(%q) = false, want true", tt.desc, tt.theme)
+		}
+		if err := Reload(); err != nil {
+			t.Fatalf("[%s] Reload: %v", tt.desc, err)
+		}
+		if got := Current().ID; got != "ru" {
+			t.Errorf("[%s] Current().ID = %q, want %q", tt.desc, got, "ru")
+		}
+		if got := Text(NameKey); got != tt.want {
+			t.Errorf("[%s] Text(%q) = %q, want %q", tt.desc, NameKey, got, tt.want)
+		}
+	}
+}
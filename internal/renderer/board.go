@@ -5,10 +5,9 @@ import (
 	"strconv"
 
 	"github.com/btmura/blockcillin/internal/game"
-	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
-func renderBoard(g *game.Game, fudge float32) bool {
+func (l *drawList) drawBoard(g *game.Game, fudge float32) bool {
 	if g.Board == nil {
 		return false
 	}
@@ -17,9 +16,6 @@ func renderBoard(g *game.Game, fudge float32) bool {
 
 	metrics := newMetrics(g, fudge)
 
-	gl.UniformMatrix4fv(projectionViewMatrixUniform, 1, false, &perspectiveProjectionViewMatrix[0])
-	gl.Uniform3fv(mixColorUniform, 1, &blackColor[0])
-
 	globalGrayscale := float32(1)
 	globalDarkness := float32(0.8)
 	var boardDarkness float32
@@ -57,17 +53,21 @@ func renderBoard(g *game.Game, fudge float32) bool {
 	if finalDarkness < boardDarkness {
 		finalDarkness = boardDarkness
 	}
-	gl.Uniform1f(mixAmountUniform, finalDarkness)
+	l.state.mixAmount = finalDarkness
 
-	gl.Uniform1i(textureUniform, int32(boardTexture)-1)
+	l.state.textureID = boardTextureID
 
-	for i := 0; i <= 2; i++ {
-		gl.Uniform1f(grayscaleUniform, globalGrayscale)
-		gl.Uniform1f(brightnessUniform, 0)
-		gl.Uniform1f(alphaUniform, 1)
+	for i := 0; i <= 1; i++ {
+		l.state.pass = drawPassOpaque
+		if i == 1 {
+			l.state.pass = drawPassTransparent
+		}
+		l.state.grayscale = globalGrayscale
+		l.state.brightness = 0
+		l.state.alpha = 1
 
 		if i == 0 {
-			renderSelector(metrics)
+			l.drawSelector(metrics)
 		}
 
 		for y, r := range b.Rings {
@@ -80,27 +80,31 @@ func renderBoard(g *game.Game, fudge float32) bool {
 						game.BlockSwappingFromRight,
 						game.BlockDroppingFromAbove,
 						game.BlockFlashing:
-						renderCellBlock(metrics, c, x, y)
+						l.drawCellBlock(metrics, c, x, y)
 
 					case game.BlockCracking, game.BlockCracked:
-						renderCellFragments(metrics, c, x, y)
+						l.drawCellFragments(metrics, c, x, y)
 					}
 
 				case 1: // draw transparent objects
 					switch c.Block.State {
 					case game.BlockExploding:
-						renderCellFragments(metrics, c, x, y)
+						l.drawCellFragments(metrics, c, x, y)
 					}
-					renderMarker(metrics, c.Marker, x, y)
+					l.drawMarker(metrics, c.Marker, x, y)
 				}
 			}
 		}
 	}
 
-	// Render the spare rings.
+	// Render the particles of exploding blocks in the transparent pass over the rings.
+	l.drawParticles(metrics)
+
+	// Render the spare rings. They are transparent since the last one fades in.
+	l.state.pass = drawPassTransparent
 
 	// Set brightness to zero for all spare rings.
-	gl.Uniform1f(brightnessUniform, 0)
+	l.state.brightness = 0
 
 	for y, r := range b.SpareRings {
 		// Set grayscale value. First spare rings becomes colored. Rest are gray.
@@ -111,42 +115,45 @@ func renderBoard(g *game.Game, fudge float32) bool {
 		if grayscale < globalGrayscale {
 			grayscale = globalGrayscale
 		}
-		gl.Uniform1f(grayscaleUniform, grayscale)
+		l.state.grayscale = grayscale
 
 		// Set alpha value. Last spare ring fades in. Rest are opaque.
 		alpha := float32(1)
 		if y == len(b.SpareRings)-1 {
 			alpha = easeInExpo(b.RiseProgress(fudge), 0, 1)
 		}
-		gl.Uniform1f(alphaUniform, alpha)
+		l.state.alpha = alpha
 
 		// Render the spare rings below the normal rings.
 		for x, c := range r.Cells {
-			renderCellBlock(metrics, c, x, y+b.RingCount)
+			l.drawCellBlock(metrics, c, x, y+b.RingCount)
 		}
 	}
 
 	return true
 }
 
-func renderSelector(metrics *metrics) {
-	gl.UniformMatrix4fv(modelMatrixUniform, 1, false, &metrics.selectorMatrix[0])
-	selectorMesh.drawElements()
+func (l *drawList) drawSelector(metrics *metrics) {
+	l.draw(selectorMeshID, metrics.selectorMatrix)
 }
 
-func renderCellBlock(metrics *metrics, c *game.Cell, x, y int) {
+func (l *drawList) drawCellBlock(metrics *metrics, c *game.Cell, x, y int) {
 	bv := float32(0)
 	if c.Block.State == game.BlockFlashing {
 		bv = pulse(metrics.g.GlobalPulse+metrics.fudge, 0, 0.5, 1.5)
 	}
-	gl.Uniform1f(brightnessUniform, bv)
+	l.state.brightness = bv
 
 	m := metrics.blockMatrix(c.Block, x, y)
-	gl.UniformMatrix4fv(modelMatrixUniform, 1, false, &m[0])
-	blockMeshes[c.Block.Color].drawElements()
+	l.draw(blockMeshID(c.Block), m)
+
+	// Draw the bomb on top of the block's front face.
+	if c.Block.Kind == game.BlockBomb {
+		l.draw(bombMeshID, m)
+	}
 }
 
-func renderCellFragments(metrics *metrics, c *game.Cell, x, y int) {
+func (l *drawList) drawCellFragments(metrics *metrics, c *game.Cell, x, y int) {
 	const (
 		nw = iota
 		ne
@@ -158,8 +165,7 @@ func renderCellFragments(metrics *metrics, c *game.Cell, x, y int) {
 		m := newScaleMatrix(sc, sc, sc)
 		m = m.mult(newTranslationMatrix(rx, ry, rz))
 		m = m.mult(metrics.blockMatrix(c.Block, x, y))
-		gl.UniformMatrix4fv(modelMatrixUniform, 1, false, &m[0])
-		fragmentMeshes[c.Block.Color][dir].drawElements()
+		l.draw(blockFragmentMeshIDs(c.Block)[dir], m)
 	}
 
 	ease := func(start, change float32) float32 {
@@ -175,8 +181,8 @@ func renderCellFragments(metrics *metrics, c *game.Cell, x, y int) {
 		bv = ease(0, 1)
 		av = ease(1, -1)
 	}
-	gl.Uniform1f(brightnessUniform, bv)
-	gl.Uniform1f(alphaUniform, av)
+	l.state.brightness = bv
+	l.state.alpha = av
 
 	const (
 		maxCrack  = 0.03
@@ -219,7 +225,7 @@ func renderCellFragments(metrics *metrics, c *game.Cell, x, y int) {
 	render(rs, ex+j, sy+j, bz, se) // back south east
 }
 
-func renderMarker(metrics *metrics, m *game.Marker, x, y int) {
+func (l *drawList) drawMarker(metrics *metrics, m *game.Marker, x, y int) {
 	switch m.State {
 	case game.MarkerShowing:
 		var val string
@@ -234,32 +240,24 @@ func renderMarker(metrics *metrics, m *game.Marker, x, y int) {
 			return
 		}
 
-		sc := float32(0.5)
+		// Scale the text so each line is one unit tall.
+		width, height := measureText(markerTextStyle, val)
+		sc := 1 / height
 
-		tx := -float32(len(val)-1) * sc
-		ty := metrics.globalTranslationY + cellTranslationY*-float32(y) + easeOutCubic(m.StateProgress(metrics.fudge), 0, 0.5)
+		tx := -width * sc / 2
+		ty := metrics.globalTranslationY + cellTranslationY*-float32(y) + easeOutCubic(m.StateProgress(metrics.fudge), 0, 0.5) - 0.5
 		tz := metrics.globalTranslationZ + cellTranslationZ/2 + 0.1
 
 		ry := metrics.globalRotationY + metrics.cellRotationY*-float32(x)
 		yq := newAxisAngleQuaternion(yAxis, ry)
 		qm := newQuaternionMatrix(yq.normalize())
 
-		gl.Uniform1f(brightnessUniform, 0)
-		gl.Uniform1f(alphaUniform, easeOutCubic(m.StateProgress(metrics.fudge), 1, -1))
-
-		for _, rune := range val {
-			text := markerRuneText[rune]
-
-			m := newScaleMatrix(sc, sc, sc)
-			m = m.mult(newTranslationMatrix(tx, ty, tz))
-			m = m.mult(qm)
-			gl.UniformMatrix4fv(modelMatrixUniform, 1, false, &m[0])
-
-			gl.Uniform1i(textureUniform, int32(text.texture)-1)
-			squareMesh.drawElements()
-			tx++
-		}
+		l.state.brightness = 0
+		l.state.alpha = easeOutCubic(m.StateProgress(metrics.fudge), 1, -1)
 
-		gl.Uniform1i(textureUniform, int32(boardTexture)-1)
+		mtx := newScaleMatrix(sc, sc, sc)
+		mtx = mtx.mult(newTranslationMatrix(tx, ty, tz))
+		mtx = mtx.mult(qm)
+		l.drawTextMatrix(markerTextStyle, val, mtx)
 	}
 }
@@ -0,0 +1,87 @@
+package renderer
+
+import (
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/btmura/blockcillin/internal/asset"
+)
+
+const (
+	// vertexShaderAssetName and fragmentShaderAssetName are the names of the shader assets.
+	vertexShaderAssetName   = "shader.vert"
+	fragmentShaderAssetName = "shader.frag"
+)
+
+// errorTextStyle is the style of the errors shown over the game.
+var errorTextStyle = textStyle{size: 16, color: color.RGBA{255, 80, 80, 255}}
+
+var (
+	// programErr is the error from loading the shaders the last time or nil if they loaded.
+	// The previous program is still used while it is set.
+	programErr error
+
+	// assetsErr is the error from loading the meshes, textures, and fonts the last time
+	// or nil if they loaded. The previous ones are still used while it is set.
+	assetsErr error
+)
+
+// reloadProgram recompiles the shaders from the current theme or development directory
+// and sets programErr.
+func reloadProgram() {
+	if programErr = loadProgram(asset.String); programErr != nil {
+		log.Printf("loadProgram: %v", programErr)
+		return
+	}
+	log.Printf("loaded shaders")
+}
+
+// reloadChanged reloads the program if a shader changed and the other assets
+// if anything else changed in the development directory.
+func reloadChanged(names []string) {
+	log.Printf("assets changed: %s", strings.Join(names, ", "))
+
+	var shaders, others bool
+	for _, name := range names {
+		switch name {
+		case vertexShaderAssetName, fragmentShaderAssetName:
+			shaders = true
+		default:
+			others = true
+		}
+	}
+	if shaders {
+		reloadProgram()
+	}
+	if others {
+		reloadGLAssets()
+	}
+}
+
+// drawErrors adds commands to draw the lines of the non-nil errors over everything else
+// starting from the top left corner.
+func (l *drawList) drawErrors(errs ...error) {
+	var lines []string
+	for _, err := range errs {
+		if err != nil {
+			lines = append(lines, strings.Split(err.Error(), "\n")...)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	l.state.pass = drawPassOverlay
+	l.state.grayscale = 0
+	l.state.brightness = 0
+	l.state.alpha = 1
+	l.state.mixAmount = 0
+
+	_, height := measureText(errorTextStyle, "")
+	y := l.height
+	for _, line := range lines {
+		y -= height
+		l.drawText(errorTextStyle, line, height/2, y)
+	}
+}
@@ -0,0 +1,45 @@
+package renderer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDrawErrors(t *testing.T) {
+	initTestText(t)
+
+	for _, tt := range []struct {
+		desc  string
+		errs  []error
+		lines int
+	}{
+		{
+			desc: "no errors",
+			errs: []error{nil, nil},
+		},
+		{
+			desc:  "error on each line of a compile error and another error",
+			errs:  []error{errors.New("failed to compile vertex shader:\nERROR: 0:1: x\nERROR: 0:2: y"), nil, errors.New("mesh not found: selector")},
+			lines: 4,
+		},
+	} {
+		l := &drawList{width: 800, height: 600, state: drawCommand{texCoordRect: fullTexCoordRect}}
+		l.drawErrors(tt.errs...)
+
+		// Each line starts lower than the one before it, so count how often the glyphs move down.
+		var lines int
+		var prevY float32
+		for i, c := range l.commands {
+			if c.pass != drawPassOverlay {
+				t.Errorf("[%s] command %d pass = %v, want %v", tt.desc, i, c.pass, drawPassOverlay)
+			}
+			if y := c.modelMatrix[13]; i == 0 || y < prevY-float32(errorTextStyle.size)/2 {
+				lines++
+				prevY = y
+			}
+		}
+		if lines != tt.lines {
+			t.Errorf("[%s] drawErrors drew %d lines, want %d", tt.desc, lines, tt.lines)
+		}
+	}
+}
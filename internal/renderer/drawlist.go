@@ -0,0 +1,190 @@
+package renderer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+// Mesh IDs of the objects in meshes.obj that are not blocks.
+const (
+	selectorMeshID = "selector"
+	squareMeshID   = "square"
+	textLineMeshID = "text_line"
+	bombMeshID     = "bomb"
+)
+
+// materialBlockMeshID is the ID of the block object that block colors and kinds without
+// objects of their own are drawn as, using the material with the color's or kind's mesh ID.
+const materialBlockMeshID = "block"
+
+// boardTextureID is the texture ID of the texture shared by the board's meshes.
+// Materials with textures of their own are drawn with those instead.
+const boardTextureID = "texture.png"
+
+var (
+	// blockColorMeshIDs maps block colors to mesh IDs.
+	blockColorMeshIDs = map[game.BlockColor]string{
+		game.Red:    "red",
+		game.Purple: "purple",
+		game.Blue:   "blue",
+		game.Cyan:   "cyan",
+		game.Green:  "green",
+		game.Yellow: "yellow",
+	}
+
+	// blockKindMeshIDs maps block kinds that hide the block's color to mesh IDs.
+	blockKindMeshIDs = map[game.BlockKind]string{
+		game.BlockRainbow: "rainbow",
+		game.BlockLocked:  "locked",
+	}
+)
+
+// drawPass is an enum that identifies how a draw command's mesh is drawn.
+//go:generate stringer -type=drawPass
+type drawPass byte
+
+const (
+	// drawPassOpaque draws opaque meshes in the board's perspective.
+	drawPassOpaque drawPass = iota
+
+	// drawPassTransparent draws meshes that may be see-through in the board's perspective.
+	drawPassTransparent
+
+	// drawPassOverlay draws flat meshes like text over the board in an orthographic projection.
+	drawPassOverlay
+)
+
+// drawCommand is a request to draw a mesh with the uniforms and texture to draw it with.
+type drawCommand struct {
+	// pass is how the mesh is drawn.
+	pass drawPass
+
+	// meshID is the ID of the mesh to draw.
+	meshID string
+
+	// modelMatrix is the mesh's model matrix.
+	modelMatrix matrix4
+
+	// textureID is the ID of the texture to draw the mesh with where its materials have no texture.
+	textureID string
+
+	// texCoordRect is the part of the texture to map the mesh's texture coordinates to
+	// as the top left corner followed by the width and height in texture coordinates.
+	texCoordRect [4]float32
+
+	// grayscale is how gray the mesh is from 0 to 1.
+	grayscale float32
+
+	// brightness is how much brighter the mesh is from 0.
+	brightness float32
+
+	// alpha is how opaque the mesh is from 0 to 1.
+	alpha float32
+
+	// mixAmount is how much the mesh is darkened from 0 to 1.
+	mixAmount float32
+}
+
+func (c drawCommand) String() string {
+	r := c.texCoordRect
+	return fmt.Sprintf("%s %s %q texCoordRect=[%.4f %.4f %.4f %.4f] grayscale=%.2f brightness=%.2f alpha=%.2f mixAmount=%.2f\n%v",
+		c.pass, c.meshID, c.textureID, r[0], r[1], r[2], r[3], c.grayscale, c.brightness, c.alpha, c.mixAmount, c.modelMatrix)
+}
+
+// drawList is an ordered list of draw commands built from the game's state.
+// It has no GL dependencies so it can be tested without a GPU.
+type drawList struct {
+	// commands are the commands in the order to draw them.
+	commands []drawCommand
+
+	// width and height are the size of the window in pixels.
+	width, height float32
+
+	// state is the pass, texture, and uniforms applied to the next commands.
+	// The draw functions change it like GL state before calling draw.
+	state drawCommand
+}
+
+// newDrawList returns the draw commands to render the game at the fudge between updates
+// in a window of the given size.
+func newDrawList(g *game.Game, fudge float32, width, height int) *drawList {
+	l := &drawList{
+		width:  float32(width),
+		height: float32(height),
+		state:  drawCommand{texCoordRect: fullTexCoordRect},
+	}
+	if l.drawBoard(g, fudge) {
+		l.drawHUD(g, fudge)
+	}
+	l.drawMenu(g, fudge)
+	return l
+}
+
+// draw adds a command to draw the mesh with the model matrix and the current state.
+func (l *drawList) draw(meshID string, modelMatrix matrix4) {
+	c := l.state
+	c.meshID = meshID
+	c.modelMatrix = modelMatrix
+	l.commands = append(l.commands, c)
+}
+
+// drawText adds commands to draw the text in the style with its lower left corner at x and y.
+func (l *drawList) drawText(s textStyle, text string, x, y float32) {
+	l.drawTextMatrix(s, text, newTranslationMatrix(x, y, 0))
+}
+
+// drawTextMatrix adds commands to draw the text in the style with its lower left corner
+// at the origin and a pixel as one unit transformed by the model matrix.
+func (l *drawList) drawTextMatrix(s textStyle, text string, modelMatrix matrix4) {
+	a := atlasFor(s)
+
+	textureID, texCoordRect := l.state.textureID, l.state.texCoordRect
+	l.state.textureID = a.id
+	for _, g := range a.layout(text).glyphs {
+		m := newScaleMatrix(g.width, g.height, 1)
+		m = m.mult(newTranslationMatrix(g.x, g.y, 0))
+		m = m.mult(modelMatrix)
+
+		l.state.texCoordRect = g.texCoordRect
+		l.draw(textLineMeshID, m)
+	}
+	l.state.textureID, l.state.texCoordRect = textureID, texCoordRect
+}
+
+// blockMeshID returns the mesh ID for the block's kind or color.
+func blockMeshID(b *game.Block) string {
+	if id, ok := blockKindMeshIDs[b.Kind]; ok {
+		return id
+	}
+	return blockColorMeshIDs[b.Color]
+}
+
+// blockFragmentMeshIDs returns the fragment mesh IDs for the block's kind or color.
+func blockFragmentMeshIDs(b *game.Block) [4]string {
+	return fragmentMeshIDs(blockMeshID(b))
+}
+
+// fragmentMeshIDs returns the IDs of the four fragment meshes of a block mesh.
+func fragmentMeshIDs(id string) [4]string {
+	return [4]string{
+		id + "_north_west",
+		id + "_north_east",
+		id + "_south_east",
+		id + "_south_west",
+	}
+}
+
+// blockMeshIDs returns the sorted mesh IDs of the block colors and kinds.
+func blockMeshIDs() []string {
+	var ids []string
+	for _, id := range blockColorMeshIDs {
+		ids = append(ids, id)
+	}
+	for _, id := range blockKindMeshIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,158 @@
+package renderer
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/game"
+	"github.com/btmura/blockcillin/internal/locale"
+	"github.com/kylelemons/godebug/diff"
+)
+
+var update = flag.Bool("update", false, "update the golden draw lists and images in testdata")
+
+func TestDrawList(t *testing.T) {
+	for _, tt := range []struct {
+		desc   string
+		golden string
+		lang   string
+		game   func() *game.Game
+	}{
+		{
+			desc:   "main menu without a board",
+			golden: "main_menu",
+			game: func() *game.Game {
+				return newTestGame(game.GameInitial, nil)
+			},
+		},
+		{
+			desc:   "main menu in a language with another font and missing keys",
+			golden: "main_menu_ru",
+			lang:   "ru",
+			game: func() *game.Game {
+				return newTestGame(game.GameInitial, nil)
+			},
+		},
+		{
+			desc:   "playing board with every kind of block and a marker",
+			golden: "playing",
+			game: func() *game.Game {
+				return newTestGame(game.GamePlaying, newTestBoard(game.BoardLive))
+			},
+		},
+		{
+			desc:   "paused board is gray and dark under the menu",
+			golden: "paused",
+			game: func() *game.Game {
+				return newTestGame(game.GamePaused, newTestBoard(game.BoardLive))
+			},
+		},
+		{
+			desc:   "entering board spins in",
+			golden: "entering",
+			game: func() *game.Game {
+				return newTestGame(game.GamePlaying, newTestBoard(game.BoardEntering))
+			},
+		},
+	} {
+		initTestText(t)
+		if tt.lang != "" {
+			locale.SetLanguage(tt.lang)
+		}
+		l := newDrawList(tt.game(), 0.5, 800, 600)
+
+		var cmds []string
+		for _, c := range l.commands {
+			cmds = append(cmds, c.String())
+		}
+		got := strings.Join(cmds, "\n\n") + "\n"
+
+		path := filepath.Join("testdata", "drawlist_"+tt.golden+".golden")
+		if *update {
+			if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+				t.Fatalf("[%s] WriteFile: %v", tt.desc, err)
+			}
+			continue
+		}
+
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("[%s] ReadFile: %v", tt.desc, err)
+		}
+		if got != string(want) {
+			t.Errorf("[%s] draw list differs from %s (-want +got):\n%s", tt.desc, path, diff.Diff(string(want), got))
+		}
+	}
+}
+
+// initTestText loads the English catalog and fonts and clears the glyph atlases
+// so that every test lays out glyphs in the same atlas positions.
+func initTestText(t *testing.T) {
+	if err := locale.Init(locale.English); err != nil {
+		t.Fatalf("locale.Init: %v", err)
+	}
+	if err := initFonts(); err != nil {
+		t.Fatalf("initFonts: %v", err)
+	}
+	atlases = map[atlasKey]*glyphAtlas{}
+}
+
+// newTestGame returns a game in the given state whose state transition has finished.
+func newTestGame(state game.GameState, b *game.Board) *game.Game {
+	g := game.New()
+
+	// Finish the initial state's transition, which only counts updates.
+	for g.StateProgress(0) < 1 {
+		g.Update()
+	}
+
+	g.State = state
+	g.Board = b
+	g.HUD = &game.HUD{Speed: 1, TimeSec: 65, Score: 120}
+	return g
+}
+
+// newTestBoard returns a small board with a block in each state that is drawn differently.
+func newTestBoard(state game.BoardState) *game.Board {
+	cell := func(state game.BlockState, color game.BlockColor, kind game.BlockKind) *game.Cell {
+		return &game.Cell{
+			Block:  &game.Block{State: state, Color: color, Kind: kind},
+			Marker: &game.Marker{},
+		}
+	}
+
+	b := &game.Board{
+		State: state,
+		Rings: []*game.Ring{
+			{Cells: []*game.Cell{
+				cell(game.BlockStatic, game.Red, game.BlockNormal),
+				cell(game.BlockFlashing, game.Blue, game.BlockNormal),
+				cell(game.BlockCracking, game.Green, game.BlockNormal),
+				cell(game.BlockExploding, game.Yellow, game.BlockNormal),
+			}},
+			{Cells: []*game.Cell{
+				cell(game.BlockStatic, game.Red, game.BlockRainbow),
+				cell(game.BlockStatic, game.Purple, game.BlockBomb),
+				cell(game.BlockCleared, game.Red, game.BlockNormal),
+				cell(game.BlockSwappingFromLeft, game.Cyan, game.BlockNormal),
+			}},
+		},
+		SpareRings: []*game.Ring{
+			{Cells: []*game.Cell{
+				cell(game.BlockStatic, game.Blue, game.BlockNormal),
+				cell(game.BlockStatic, game.Cyan, game.BlockLocked),
+				cell(game.BlockStatic, game.Green, game.BlockNormal),
+				cell(game.BlockStatic, game.Yellow, game.BlockNormal),
+			}},
+		},
+		RingCount: 2,
+		CellCount: 4,
+		Selector:  &game.Selector{X: 1},
+		Y:         0.5,
+	}
+	b.Rings[0].Cells[3].Marker = &game.Marker{State: game.MarkerShowing, ChainLevel: 1}
+	return b
+}
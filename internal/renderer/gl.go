@@ -10,6 +10,12 @@ import (
 	_ "image/png" // needed to decode PNGs
 )
 
+// shaderTypeNames maps shader type to name for errors.
+var shaderTypeNames = map[uint32]string{
+	gl.VERTEX_SHADER:   "vertex shader",
+	gl.FRAGMENT_SHADER: "fragment shader",
+}
+
 func createProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
 	vs, err := createShader(vertexShaderSource, gl.VERTEX_SHADER)
 	if err != nil {
@@ -18,6 +24,7 @@ func createProgram(vertexShaderSource, fragmentShaderSource string) (uint32, err
 
 	fs, err := createShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
 	if err != nil {
+		gl.DeleteShader(vs)
 		return 0, err
 	}
 
@@ -26,6 +33,10 @@ func createProgram(vertexShaderSource, fragmentShaderSource string) (uint32, err
 	gl.AttachShader(program, fs)
 	gl.LinkProgram(program)
 
+	// The shaders are only deleted once the program that they are attached to is deleted.
+	gl.DeleteShader(vs)
+	gl.DeleteShader(fs)
+
 	var status int32
 	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
 	if status == gl.FALSE {
@@ -35,12 +46,10 @@ func createProgram(vertexShaderSource, fragmentShaderSource string) (uint32, err
 		log := strings.Repeat("\x00", int(logLength)+1)
 		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
 
-		return 0, fmt.Errorf("failed to create program: %q", log)
+		gl.DeleteProgram(program)
+		return 0, fmt.Errorf("failed to create program:\n%s", strings.TrimRight(log, "\x00\n"))
 	}
 
-	gl.DeleteShader(vs)
-	gl.DeleteShader(fs)
-
 	return program, nil
 }
 
@@ -59,16 +68,18 @@ func createShader(shaderSource string, shaderType uint32) (uint32, error) {
 		log := strings.Repeat("\x00", int(logLength)+1)
 		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
 
-		return 0, fmt.Errorf("failed to compile shader: type: %d source: %q log: %q", shaderType, shaderSource, log)
+		gl.DeleteShader(shader)
+
+		// Return the log without the source, since it has the line numbers and is shown on screen in development mode.
+		return 0, fmt.Errorf("failed to compile %s:\n%s", shaderTypeNames[shaderType], strings.TrimRight(log, "\x00\n"))
 	}
 
 	return shader, nil
 }
 
-func createTexture(textureUnit uint32, rgba *image.RGBA) (uint32, error) {
+func createTexture(rgba *image.RGBA) (uint32, error) {
 	var texture uint32
 	gl.GenTextures(1, &texture)
-	gl.ActiveTexture(textureUnit)
 	gl.BindTexture(gl.TEXTURE_2D, texture)
 
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
@@ -81,6 +92,11 @@ func createTexture(textureUnit uint32, rgba *image.RGBA) (uint32, error) {
 	return texture, nil
 }
 
+func updateTexture(texture uint32, rgba *image.RGBA) {
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
+}
+
 func createArrayBuffer(data []float32) uint32 {
 	var name uint32
 	gl.GenBuffers(1, &name)
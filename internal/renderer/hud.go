@@ -5,38 +5,26 @@ import (
 	"strconv"
 
 	"github.com/btmura/blockcillin/internal/game"
-	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
-func renderHUD(g *game.Game, fudge float32) {
-	gl.UniformMatrix4fv(projectionViewMatrixUniform, 1, false, &orthoProjectionViewMatrix[0])
-	gl.Uniform1f(grayscaleUniform, 0)
-	gl.Uniform1f(brightnessUniform, 0)
-	gl.Uniform1f(alphaUniform, 1)
+func (l *drawList) drawHUD(g *game.Game, fudge float32) {
+	l.state.pass = drawPassOverlay
+	l.state.grayscale = 0
+	l.state.brightness = 0
+	l.state.alpha = 1
 
 	i := 1
 	renderText := func(item game.HUDItem, val string) {
-		text := hudItemText[item]
-		x := float32(winWidth)/4*float32(i) - text.width/2
-		y := float32(winHeight) - text.height*2
-		text.render(x, y)
-
-		var valWidth, valHeight float32
-		for _, rune := range val {
-			text := hudRuneText[rune]
-			valWidth += text.width
-			if text.height > valHeight {
-				valHeight = text.height
-			}
-		}
-
-		x = float32(winWidth)/4*float32(i) - valWidth/2
+		text := item.Text()
+		width, height := measureText(hudTextStyle, text)
+		x := l.width/4*float32(i) - width/2
+		y := l.height - height*2
+		l.drawText(hudTextStyle, text, x, y)
+
+		valWidth, valHeight := measureText(hudTextStyle, val)
+		x = l.width/4*float32(i) - valWidth/2
 		y -= valHeight * 1.5
-		for _, rune := range val {
-			text := hudRuneText[rune]
-			text.render(x, y)
-			x += text.width
-		}
+		l.drawText(hudTextStyle, val, x, y)
 
 		i++
 	}
@@ -4,10 +4,9 @@ import (
 	"strconv"
 
 	"github.com/btmura/blockcillin/internal/game"
-	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
-func renderMenu(g *game.Game, fudge float32) {
+func (l *drawList) drawMenu(g *game.Game, fudge float32) {
 	ease := func(start, change float32) float32 {
 		return easeOutCubic(g.StateProgress(fudge), start, change)
 	}
@@ -26,56 +25,30 @@ func renderMenu(g *game.Game, fudge float32) {
 		return
 	}
 
-	gl.UniformMatrix4fv(projectionViewMatrixUniform, 1, false, &orthoProjectionViewMatrix[0])
-	gl.Uniform1f(grayscaleUniform, 0)
-	gl.Uniform1f(brightnessUniform, 0)
-	gl.Uniform1f(alphaUniform, alpha)
-	gl.Uniform1f(mixAmountUniform, 0)
+	l.state.pass = drawPassOverlay
+	l.state.grayscale = 0
+	l.state.brightness = 0
+	l.state.alpha = alpha
+	l.state.mixAmount = 0
 
 	menu := g.Menu
-	titleText := menuTitleText[menu.ID]
-	totalHeight := titleText.height * 2
+	title := menu.ID.Text()
+	_, titleHeight := measureText(menuTitleTextStyle, title)
+	totalHeight := titleHeight * 2
 	for _, item := range menu.Items {
-		totalHeight += float32(menuItemFontSize) * 2
+		totalHeight += float32(menuItemTextStyle.size) * 2
 		if !item.SingleChoice() {
-			totalHeight += float32(menuItemFontSize) * 2
+			totalHeight += float32(menuItemTextStyle.size) * 2
 		}
 	}
 
-	currentY := (float32(winHeight) + totalHeight) / 2
+	currentY := (l.height + totalHeight) / 2
 
-	centerX := func(txt *renderableText) float32 {
-		return (float32(winWidth) - txt.width) / 2
-	}
-
-	renderText := func(text *renderableText) {
-		currentY -= text.height
-		text.render(centerX(text), currentY)
-		currentY -= text.height // add spacing for next item
-	}
-
-	// TODO(btmura): split these out into separate functions
-
-	renderSlider := func(item *game.MenuItem) {
-		val := strconv.Itoa(item.Slider.Value)
-
-		var valWidth, valHeight float32
-		for _, rune := range val {
-			text := menuRuneText[rune]
-			valWidth += text.width
-			if text.height > valHeight {
-				valHeight = text.height
-			}
-		}
-
-		currentY -= valHeight
-		x := (float32(winWidth) - valWidth) / 2
-		for _, rune := range val {
-			text := menuRuneText[rune]
-			text.render(x, currentY)
-			x += text.width
-		}
-		currentY -= valHeight
+	renderText := func(s textStyle, text string) {
+		width, height := measureText(s, text)
+		currentY -= height
+		l.drawText(s, text, (l.width-width)/2, currentY)
+		currentY -= height // add spacing for next item
 	}
 
 	renderMenuItem := func(index int, item *game.MenuItem) {
@@ -92,18 +65,18 @@ func renderMenu(g *game.Game, fudge float32) {
 				brightness = 1
 			}
 		}
-		gl.Uniform1f(brightnessUniform, brightness)
-		renderText(menuItemText[item.ID])
+		l.state.brightness = brightness
+		renderText(menuItemTextStyle, item.ID.Text())
 		switch {
 		case item.Selector != nil:
-			renderText(menuChoiceText[item.Selector.Value()])
+			renderText(menuItemTextStyle, item.Selector.Text())
 
 		case item.Slider != nil:
-			renderSlider(item)
+			renderText(menuItemTextStyle, strconv.Itoa(item.Slider.Value))
 		}
 	}
 
-	renderText(titleText)
+	renderText(menuTitleTextStyle, title)
 	for i, item := range menu.Items {
 		renderMenuItem(i, item)
 	}
@@ -11,33 +11,136 @@ type mesh struct {
 	// id is the object's ID in the OBJ file.
 	id string
 
+	// vertices are the vertex attributes shared by all the meshes from the same OBJ file.
+	vertices *meshVertices
+
+	// indices are the indices of the vertices of each triangle in the mesh.
+	indices []uint16
+
 	// vao is the vertex array object name.
 	vao uint32
 
-	// count is how many elements to render using gl.DrawElements.
-	count int32
+	// ebo is the element array buffer name.
+	ebo uint32
+
+	// parts are the ranges of indices drawn with each material in the order of the indices.
+	parts []*meshPart
 }
 
-func (m *mesh) drawElements() {
+// meshPart is a range of a mesh's indices that is drawn with one material.
+type meshPart struct {
+	// material is the material to draw the part with.
+	material *objMaterial
+
+	// offset is the position of the part's first index in the mesh's indices.
+	offset int
+
+	// count is how many indices are in the part.
+	count int
+}
+
+// drawElements draws each of the mesh's parts with its material. Parts whose materials
+// have no texture of their own are drawn with the texture.
+func (m *mesh) drawElements(textureID string) {
 	gl.BindVertexArray(m.vao)
-	gl.DrawElements(gl.TRIANGLES, m.count, gl.UNSIGNED_SHORT, gl.Ptr(nil))
+	for _, p := range m.parts {
+		bindMaterial(p.material, textureID)
+		gl.DrawElements(gl.TRIANGLES, int32(p.count), gl.UNSIGNED_SHORT, gl.PtrOffset(p.offset*2 /* bytes per index */))
+	}
 	gl.BindVertexArray(0)
 }
 
+// meshVertices are the vertex attributes of meshes that their indices refer to.
+type meshVertices struct {
+	// positions are the x, y, and z of each vertex.
+	positions []float32
+
+	// normals are the x, y, and z of each vertex's normal.
+	normals []float32
+
+	// texCoords are the s and t of each vertex's texture coordinate with the origin at the lower left.
+	texCoords []float32
+
+	// buffers are the names of the array buffers of the positions, normals, and texture coordinates
+	// once they are uploaded to GL.
+	buffers [3]uint32
+}
+
+// createMeshes makes meshes from the objects and uploads them to GL for drawElements.
 func createMeshes(objs []*obj) []*mesh {
+	meshes := newMeshes(objs)
+	if len(meshes) == 0 {
+		return nil
+	}
+
+	mv := meshes[0].vertices
+	vbo := createArrayBuffer(mv.positions)
+	nbo := createArrayBuffer(mv.normals)
+	tbo := createArrayBuffer(mv.texCoords)
+	mv.buffers = [3]uint32{vbo, nbo, tbo}
+
+	const (
+		positionLocation = iota
+		normalLocation
+		texCoordLocation
+	)
+
+	for _, m := range meshes {
+		gl.GenVertexArrays(1, &m.vao)
+		gl.BindVertexArray(m.vao)
+
+		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+		gl.EnableVertexAttribArray(positionLocation)
+		gl.VertexAttribPointer(positionLocation, 3, gl.FLOAT, false, 0, gl.PtrOffset(0))
+
+		gl.BindBuffer(gl.ARRAY_BUFFER, nbo)
+		gl.EnableVertexAttribArray(normalLocation)
+		gl.VertexAttribPointer(normalLocation, 3, gl.FLOAT, false, 0, gl.PtrOffset(0))
+
+		gl.BindBuffer(gl.ARRAY_BUFFER, tbo)
+		gl.EnableVertexAttribArray(texCoordLocation)
+		gl.VertexAttribPointer(texCoordLocation, 2, gl.FLOAT, false, 0, gl.PtrOffset(0))
+
+		m.ebo = createElementArrayBuffer(m.indices)
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ebo)
+		gl.BindVertexArray(0)
+	}
+
+	return meshes
+}
+
+// deleteMeshes deletes the meshes' vertex array objects and buffers from GL.
+func deleteMeshes(meshes map[string]*mesh) {
+	deleted := map[*meshVertices]bool{}
+	for _, m := range meshes {
+		gl.DeleteVertexArrays(1, &m.vao)
+		gl.DeleteBuffers(1, &m.ebo)
+		if mv := m.vertices; !deleted[mv] {
+			gl.DeleteBuffers(int32(len(mv.buffers)), &mv.buffers[0])
+			deleted[mv] = true
+		}
+	}
+}
+
+// newMeshes makes meshes from the objects that share one set of vertex attributes
+// without uploading them to GL.
+func newMeshes(objs []*obj) []*mesh {
 	var vertexTable []*objVertex
 	var normalTable []*objNormal
 	var texCoordTable []*objTexCoord
 
-	var vertices []float32
-	var normals []float32
-	var texCoords []float32
+	mv := &meshVertices{}
 
-	elementIndexMap := map[objFaceElement]uint16{}
+	// meshElement is a face element with the flat normal of its face if it has no normal,
+	// since elements without normals are shared only by faces facing the same way.
+	type meshElement struct {
+		objFaceElement
+		flatNormal vector3
+	}
+	elementIndexMap := map[meshElement]uint16{}
 	var nextIndex uint16
 
 	var meshes []*mesh
-	var iboNames []uint32
 
 	for _, o := range objs {
 		for _, v := range o.vertices {
@@ -52,22 +155,45 @@ func createMeshes(objs []*obj) []*mesh {
 
 		var indices []uint16
 		for _, f := range o.faces {
-			for _, e := range f {
+			var flatNormal vector3
+			if f[0].normalIndex == 0 || f[1].normalIndex == 0 || f[2].normalIndex == 0 {
+				position := func(e objFaceElement) vector3 {
+					v := vertexTable[e.vertexIndex-1]
+					return vector3{v.x, v.y, v.z}
+				}
+				p0, p1, p2 := position(f[0]), position(f[1]), position(f[2])
+				flatNormal = p1.sub(p0).cross(p2.sub(p0)).normalize()
+			}
+
+			for _, fe := range f {
+				e := meshElement{objFaceElement: fe}
+				if fe.normalIndex == 0 {
+					e.flatNormal = flatNormal
+				}
+
 				if _, exists := elementIndexMap[e]; !exists {
 					elementIndexMap[e] = nextIndex
 					nextIndex++
 
 					v := vertexTable[e.vertexIndex-1]
-					vertices = append(vertices, v.x, v.y, v.z)
+					mv.positions = append(mv.positions, v.x, v.y, v.z)
 
-					n := normalTable[e.normalIndex-1]
-					normals = append(normals, n.x, n.y, n.z)
+					if e.normalIndex != 0 {
+						n := normalTable[e.normalIndex-1]
+						mv.normals = append(mv.normals, n.x, n.y, n.z)
+					} else {
+						mv.normals = append(mv.normals, e.flatNormal.x, e.flatNormal.y, e.flatNormal.z)
+					}
 
 					// Flip the y-axis to convert from OBJ to OpenGL.
 					// OpenGL considers the origin to be lower left.
 					// OBJ considers the origin to be upper left.
-					tc := texCoordTable[e.texCoordIndex-1]
-					texCoords = append(texCoords, tc.s, 1.0-tc.t)
+					// Elements without texture coordinates use the texture's corner.
+					var tc objTexCoord
+					if e.texCoordIndex != 0 {
+						tc = *texCoordTable[e.texCoordIndex-1]
+					}
+					mv.texCoords = append(mv.texCoords, tc.s, 1.0-tc.t)
 				}
 
 				indices = append(indices, elementIndexMap[e])
@@ -75,45 +201,72 @@ func createMeshes(objs []*obj) []*mesh {
 		}
 
 		meshes = append(meshes, &mesh{
-			id:    o.id,
-			count: int32(len(indices)),
+			id:       o.id,
+			vertices: mv,
+			indices:  indices,
+			parts:    newMeshParts(o),
 		})
-		iboNames = append(iboNames, createElementArrayBuffer(indices))
 	}
 
 	log.Printf("vertices: %d", len(vertexTable))
 	log.Printf("normals: %d", len(normalTable))
 	log.Printf("texCoords: %d", len(texCoordTable))
 
-	vbo := createArrayBuffer(vertices)
-	nbo := createArrayBuffer(normals)
-	tbo := createArrayBuffer(texCoords)
-
-	const (
-		positionLocation = iota
-		normalLocation
-		texCoordLocation
-	)
+	return meshes
+}
 
-	for i, m := range meshes {
-		gl.GenVertexArrays(1, &m.vao)
-		gl.BindVertexArray(m.vao)
+// newMeshParts splits the object's triangles into parts by the materials of its usemtl statements.
+// Triangles before the first usemtl statement are drawn with the default material.
+func newMeshParts(o *obj) []*meshPart {
+	var parts []*meshPart
+	add := func(material *objMaterial, startFace, endFace int) {
+		if endFace > startFace {
+			parts = append(parts, &meshPart{
+				material: material,
+				offset:   startFace * numFaceElements,
+				count:    (endFace - startFace) * numFaceElements,
+			})
+		}
+	}
 
-		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-		gl.EnableVertexAttribArray(positionLocation)
-		gl.VertexAttribPointer(positionLocation, 3, gl.FLOAT, false, 0, gl.PtrOffset(0))
+	material, startFace := defaultMaterial, 0
+	for _, u := range o.materialUses {
+		add(material, startFace, u.faceIndex)
+		material, startFace = u.material, u.faceIndex
+	}
+	add(material, startFace, len(o.faces))
+	return parts
+}
 
-		gl.BindBuffer(gl.ARRAY_BUFFER, nbo)
-		gl.EnableVertexAttribArray(normalLocation)
-		gl.VertexAttribPointer(normalLocation, 3, gl.FLOAT, false, 0, gl.PtrOffset(0))
+// addMaterialBlockObjs adds objects for the block colors and kinds that have a material but no
+// object of their own. They share the faces of the block object and its fragments and are drawn
+// with the material instead, so new colors and skins only need a material in the MTL file.
+func addMaterialBlockObjs(objs []*obj, materials map[string]*objMaterial) []*obj {
+	byID := map[string]*obj{}
+	for _, o := range objs {
+		byID[o.id] = o
+	}
 
-		gl.BindBuffer(gl.ARRAY_BUFFER, tbo)
-		gl.EnableVertexAttribArray(texCoordLocation)
-		gl.VertexAttribPointer(texCoordLocation, 2, gl.FLOAT, false, 0, gl.PtrOffset(0))
+	for _, id := range blockMeshIDs() {
+		m, ok := materials[id]
+		if !ok || byID[id] != nil {
+			continue
+		}
 
-		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, iboNames[i])
-		gl.BindVertexArray(0)
+		srcFragments, dstFragments := fragmentMeshIDs(materialBlockMeshID), fragmentMeshIDs(id)
+		srcIDs := append([]string{materialBlockMeshID}, srcFragments[:]...)
+		dstIDs := append([]string{id}, dstFragments[:]...)
+		for i, srcID := range srcIDs {
+			src := byID[srcID]
+			if src == nil || byID[dstIDs[i]] != nil {
+				continue
+			}
+			objs = append(objs, &obj{
+				id:           dstIDs[i],
+				faces:        src.faces,
+				materialUses: []*objMaterialUse{{material: m}},
+			})
+		}
 	}
-
-	return meshes
+	return objs
 }
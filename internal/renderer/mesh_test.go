@@ -0,0 +1,200 @@
+package renderer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewMeshes(t *testing.T) {
+	for _, tt := range []struct {
+		desc          string
+		input         string
+		wantPositions []float32
+		wantNormals   []float32
+		wantTexCoords []float32
+		wantIndices   []uint16
+	}{
+		{
+			desc: "given normals and texture coordinates with the t-axis flipped",
+			input: `
+				o Triangle
+				v 0 0 0
+				v 1 0 0
+				v 0 1 0
+				vt 0 0
+				vt 1 0
+				vt 0 1
+				vn 0 0 1
+				f 1/1/1 2/2/1 3/3/1
+			`,
+			wantPositions: []float32{0, 0, 0, 1, 0, 0, 0, 1, 0},
+			wantNormals:   []float32{0, 0, 1, 0, 0, 1, 0, 0, 1},
+			wantTexCoords: []float32{0, 1, 1, 1, 0, 0},
+			wantIndices:   []uint16{0, 1, 2},
+		},
+		{
+			desc: "flat normals for faces without normals split shared vertices",
+			input: `
+				o Corner
+				v 0 0 0
+				v 1 0 0
+				v 0 1 0
+				v 0 0 1
+				f 1 2 3
+				f 1 4 2
+			`,
+			wantPositions: []float32{0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, 1, 0, 0},
+			wantNormals:   []float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 1, 0, 0, 1, 0, 0, 1, 0},
+			wantTexCoords: []float32{0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1},
+			wantIndices:   []uint16{0, 1, 2, 3, 4, 5},
+		},
+		{
+			desc: "triangulated quad shares vertices with the same flat normal",
+			input: `
+				o Square
+				v 0 0 0
+				v 1 0 0
+				v 1 1 0
+				v 0 1 0
+				f 1 2 3 4
+			`,
+			wantPositions: []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0},
+			wantNormals:   []float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1},
+			wantTexCoords: []float32{0, 1, 0, 1, 0, 1, 0, 1},
+			wantIndices:   []uint16{0, 1, 2, 0, 2, 3},
+		},
+	} {
+		objs, _, err := decodeObjs(strings.NewReader(tt.input), nil)
+		if err != nil {
+			t.Errorf("[%s] decodeObjs(%q) err = %v, want nil", tt.desc, tt.input, err)
+			continue
+		}
+
+		m := newMeshes(objs)[0]
+		if got := m.vertices.positions; !reflect.DeepEqual(got, tt.wantPositions) {
+			t.Errorf("[%s] positions = %v, want %v", tt.desc, got, tt.wantPositions)
+		}
+		if got := m.vertices.normals; !reflect.DeepEqual(got, tt.wantNormals) {
+			t.Errorf("[%s] normals = %v, want %v", tt.desc, got, tt.wantNormals)
+		}
+		if got := m.vertices.texCoords; !reflect.DeepEqual(got, tt.wantTexCoords) {
+			t.Errorf("[%s] texCoords = %v, want %v", tt.desc, got, tt.wantTexCoords)
+		}
+		if got := m.indices; !reflect.DeepEqual(got, tt.wantIndices) {
+			t.Errorf("[%s] indices = %v, want %v", tt.desc, got, tt.wantIndices)
+		}
+	}
+}
+
+func TestNewMeshParts(t *testing.T) {
+	red := &objMaterial{name: "red"}
+	blue := &objMaterial{name: "blue"}
+
+	for _, tt := range []struct {
+		desc         string
+		numFaces     int
+		materialUses []*objMaterialUse
+		want         []meshPart
+	}{
+		{
+			desc:     "no usemtl statements",
+			numFaces: 2,
+			want:     []meshPart{{defaultMaterial, 0, 6}},
+		},
+		{
+			desc:         "faces before the first usemtl statement",
+			numFaces:     3,
+			materialUses: []*objMaterialUse{{red, 1}},
+			want:         []meshPart{{defaultMaterial, 0, 3}, {red, 3, 6}},
+		},
+		{
+			desc:         "usemtl statements without faces between them",
+			numFaces:     2,
+			materialUses: []*objMaterialUse{{red, 0}, {blue, 0}, {red, 1}, {blue, 2}},
+			want:         []meshPart{{blue, 0, 3}, {red, 3, 3}},
+		},
+	} {
+		o := &obj{
+			faces:        make([]*objFace, tt.numFaces),
+			materialUses: tt.materialUses,
+		}
+
+		var got []meshPart
+		for _, p := range newMeshParts(o) {
+			got = append(got, *p)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] newMeshParts() = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestAddMaterialBlockObjs(t *testing.T) {
+	input := `
+		mtllib blocks.mtl
+		o block
+		v 0 0 0
+		v 1 0 0
+		v 0 1 0
+		f 1 2 3
+		o block_north_west
+		f 1 2 3
+		o block_north_east
+		f 1 2 3
+		o block_south_east
+		f 1 2 3
+		o block_south_west
+		f 1 2 3
+		o red
+		f 1 2 3
+	`
+	open := func(name string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(`
+			newmtl red
+			newmtl blue
+			Kd 0 0 1
+		`)), nil
+	}
+
+	objs, materials, err := decodeObjs(strings.NewReader(input), open)
+	if err != nil {
+		t.Fatalf("decodeObjs(%q) err = %v, want nil", input, err)
+	}
+
+	var got []string
+	for _, o := range addMaterialBlockObjs(objs, materials) {
+		var names []string
+		for _, u := range o.materialUses {
+			names = append(names, u.material.name)
+		}
+		got = append(got, fmt.Sprintf("%s%v", o.id, names))
+	}
+
+	// Red has an object of its own, and the other colors and kinds have no materials.
+	want := []string{
+		"block[]",
+		"block_north_west[]",
+		"block_north_east[]",
+		"block_south_east[]",
+		"block_south_west[]",
+		"red[]",
+		"blue[blue]",
+		"blue_north_west[blue]",
+		"blue_north_east[blue]",
+		"blue_south_east[blue]",
+		"blue_south_west[blue]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("addMaterialBlockObjs() = %v, want %v", got, want)
+	}
+
+	// The added objects share the block's vertices, so they add no vertices to the meshes.
+	ms := newMeshes(addMaterialBlockObjs(objs, materials))
+	if got, want := len(ms[0].vertices.positions), 9; got != want {
+		t.Errorf("len(positions) = %d, want %d", got, want)
+	}
+}
@@ -134,11 +134,16 @@ func (m *metrics) blockMatrix(b *game.Block, x, y int) matrix4 {
 		return 0
 	}
 
-	ty := m.globalTranslationY + cellTranslationY*(-float32(y)+blockRelativeY())
-	ry := m.globalRotationY + m.cellRotationY*(-float32(x)-blockRelativeX())
-
 	mtx := newXRotationMatrix(blockRotationX())
-	mtx = mtx.mult(newTranslationMatrix(0, ty, m.globalTranslationZ))
-	mtx = mtx.mult(newQuaternionMatrix(newAxisAngleQuaternion(yAxis, ry).normalize()))
-	return mtx
+	return mtx.mult(m.cellMatrix(float32(x)+blockRelativeX(), float32(y)-blockRelativeY()))
+}
+
+// cellMatrix returns the model matrix of the cell at x and y, which can be between cells.
+// The cell's z-axis points out of the board and its y-axis points up.
+func (m *metrics) cellMatrix(x, y float32) matrix4 {
+	ty := m.globalTranslationY - cellTranslationY*y
+	ry := m.globalRotationY - m.cellRotationY*x
+
+	mtx := newTranslationMatrix(0, ty, m.globalTranslationZ)
+	return mtx.mult(newQuaternionMatrix(newAxisAngleQuaternion(yAxis, ry).normalize()))
 }
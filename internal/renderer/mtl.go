@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// objMaterial is a material from an MTL file that OBJ files refer to with usemtl statements.
+type objMaterial struct {
+	// name is the material's name from its newmtl statement.
+	name string
+
+	// ambient is the ambient color from the Ka statement.
+	ambient [3]float32
+
+	// diffuse is the diffuse color from the Kd statement. It defaults to white.
+	diffuse [3]float32
+
+	// specular is the specular color from the Ks statement.
+	specular [3]float32
+
+	// emissive is the emissive color from the Ke statement.
+	emissive [3]float32
+
+	// specularExponent is the shininess from the Ns statement.
+	specularExponent float32
+
+	// dissolve is the opacity from the d statement or 1 minus the Tr statement. It defaults to 1.
+	dissolve float32
+
+	// diffuseMap is the name of the texture asset from the map_Kd statement or empty if there is none.
+	diffuseMap string
+}
+
+// defaultMaterial is the material of faces without a usemtl statement. It is white without
+// a texture of its own, so the faces are drawn with the draw command's texture as is.
+var defaultMaterial = newObjMaterial("default")
+
+// newObjMaterial returns a material with the defaults of an MTL file's material.
+func newObjMaterial(name string) *objMaterial {
+	return &objMaterial{
+		name:     name,
+		diffuse:  [3]float32{1, 1, 1},
+		dissolve: 1,
+	}
+}
+
+// textureID returns the ID of the material's texture or the fallback if it has none.
+func (m *objMaterial) textureID(fallback string) string {
+	if m.diffuseMap != "" {
+		return m.diffuseMap
+	}
+	return fallback
+}
+
+// decodeMtl decodes the materials in an MTL file. Statements that the renderer does not use
+// like illum and the other texture maps are ignored.
+func decodeMtl(r io.Reader) ([]*objMaterial, error) {
+	var allMaterials []*objMaterial
+	var current *objMaterial
+
+	// decodeLine decodes a line and returns an error without the line's context.
+	decodeLine := func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			return nil
+		}
+
+		if fields[0] == "newmtl" {
+			if len(fields) != 2 {
+				return errors.New("newmtl should have a material name")
+			}
+			for _, m := range allMaterials {
+				if m.name == fields[1] {
+					return fmt.Errorf("duplicate material: %s", fields[1])
+				}
+			}
+			current = newObjMaterial(fields[1])
+			allMaterials = append(allMaterials, current)
+			return nil
+		}
+
+		switch fields[0] {
+		case "Ka", "Kd", "Ks", "Ke", "Ns", "d", "Tr", "map_Kd":
+			if current == nil {
+				return errors.New("missing newmtl")
+			}
+		}
+
+		var err error
+		switch fields[0] {
+		case "Ka":
+			current.ambient, err = decodeMtlColor(fields)
+		case "Kd":
+			current.diffuse, err = decodeMtlColor(fields)
+		case "Ks":
+			current.specular, err = decodeMtlColor(fields)
+		case "Ke":
+			current.emissive, err = decodeMtlColor(fields)
+		case "Ns":
+			current.specularExponent, err = decodeMtlFloat(fields)
+		case "d":
+			current.dissolve, err = decodeMtlFloat(fields)
+		case "Tr":
+			var tr float32
+			tr, err = decodeMtlFloat(fields)
+			current.dissolve = 1 - tr
+		case "map_Kd":
+			// Options like -clamp come before the texture's name, which is last.
+			if len(fields) < 2 {
+				return errors.New("map_Kd should have a texture name")
+			}
+			current.diffuseMap = fields[len(fields)-1]
+		}
+		return err
+	}
+
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		if err := decodeLine(strings.TrimSpace(sc.Text())); err != nil {
+			if current != nil {
+				return nil, fmt.Errorf("line %d: material %s: %v", lineNum, current.name, err)
+			}
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return allMaterials, nil
+}
+
+// decodeMtlColor decodes the red, green, and blue of a statement like "Kd 1 0.5 0".
+func decodeMtlColor(fields []string) ([3]float32, error) {
+	var c [3]float32
+	if len(fields) != 4 {
+		return c, fmt.Errorf("%s should have a red, green, and blue", fields[0])
+	}
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return c, fmt.Errorf("bad %s: %q", fields[0], f)
+		}
+		c[i] = float32(v)
+	}
+	return c, nil
+}
+
+// decodeMtlFloat decodes the value of a statement like "Ns 96".
+func decodeMtlFloat(fields []string) (float32, error) {
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("%s should have one value", fields[0])
+	}
+	v, err := strconv.ParseFloat(fields[1], 32)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s: %q", fields[0], fields[1])
+	}
+	return float32(v), nil
+}
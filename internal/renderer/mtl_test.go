@@ -0,0 +1,90 @@
+package renderer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeMtl(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		input   string
+		want    []*objMaterial
+		wantErr error
+	}{
+		{
+			desc: "materials with colors, shininess, opacity, and textures",
+			input: `
+				# Blender MTL File: 'meshes.blend'
+				newmtl Glow
+				Ns 96.078431
+				Ka 1.000000 1.000000 1.000000
+				Kd 0.640000 0.100000 0.100000
+				Ks 0.500000 0.500000 0.500000
+				Ke 0.200000 0.000000 0.000000
+				Ni 1.000000
+				d 0.500000
+				illum 2
+				map_Kd -clamp on texture.png
+
+				newmtl Defaults
+				Tr 0.25
+			`,
+			want: []*objMaterial{
+				{
+					name:             "Glow",
+					ambient:          [3]float32{1, 1, 1},
+					diffuse:          [3]float32{0.64, 0.1, 0.1},
+					specular:         [3]float32{0.5, 0.5, 0.5},
+					emissive:         [3]float32{0.2, 0, 0},
+					specularExponent: 96.078431,
+					dissolve:         0.5,
+					diffuseMap:       "texture.png",
+				},
+				{
+					name:     "Defaults",
+					diffuse:  [3]float32{1, 1, 1},
+					dissolve: 0.75,
+				},
+			},
+		},
+		{
+			desc: "statement before newmtl",
+			input: `
+				Kd 1 1 1
+			`,
+			wantErr: errors.New("line 2: missing newmtl"),
+		},
+		{
+			desc: "color with too few values",
+			input: `
+				newmtl Red
+				Kd 1 0
+			`,
+			wantErr: errors.New("line 3: material Red: Kd should have a red, green, and blue"),
+		},
+		{
+			desc: "bad value",
+			input: `
+				newmtl Red
+				Ns shiny
+			`,
+			wantErr: errors.New(`line 3: material Red: bad Ns: "shiny"`),
+		},
+		{
+			desc: "duplicate material",
+			input: `
+				newmtl Red
+				newmtl Red
+			`,
+			wantErr: errors.New("line 3: material Red: duplicate material: Red"),
+		},
+	} {
+		got, gotErr := decodeMtl(strings.NewReader(tt.input))
+		if !reflect.DeepEqual(got, tt.want) || !errorContains(gotErr, tt.wantErr) {
+			t.Errorf("[%s] decodeMtl(%q) = (%v, %v), want (%v, %v)", tt.desc, tt.input, pp(got), gotErr, pp(tt.want), tt.wantErr)
+		}
+	}
+}
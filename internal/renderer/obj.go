@@ -15,6 +15,10 @@ type obj struct {
 	texCoords []*objTexCoord
 	normals   []*objNormal
 	faces     []*objFace
+
+	// materialUses are the materials applied to the object's faces by usemtl statements
+	// in the order of the faces or nil if the object has no usemtl statements.
+	materialUses []*objMaterialUse
 }
 
 type objVertex struct {
@@ -34,10 +38,11 @@ type objNormal struct {
 	z float32
 }
 
-// numFaceElements is the number of required face elements. Only triangles are supported.
+// numFaceElements is the number of elements of each face after faces are triangulated.
 const numFaceElements = 3
 
-// objFace is a face described by ObjFaceElements.
+// objFace is a triangle described by ObjFaceElements.
+// Faces with more elements are triangulated into several triangles when decoded.
 type objFace [numFaceElements]objFaceElement
 
 // objFaceElement describes one point of a face.
@@ -50,68 +55,143 @@ type objFaceElement struct {
 	texCoordIndex int
 
 	// normalIndex specifies an optional normal by global index starting from 1.
+	// It is 0 if no normal was specified, so the face's flat normal is used instead.
 	normalIndex int
 }
 
-func decodeObjs(r io.Reader) ([]*obj, error) {
+// objMaterialUse is a usemtl statement that applies a material to the faces after it.
+type objMaterialUse struct {
+	// material is the material to apply.
+	material *objMaterial
+
+	// faceIndex is the index of the first of the object's faces that the material applies to.
+	faceIndex int
+}
+
+// objCounts are how many vertices, texture coordinates, and normals have been decoded
+// so far in an OBJ file, which negative indices are relative to.
+type objCounts struct {
+	vertices, texCoords, normals int
+}
+
+// decodeObjs decodes the objects in the OBJ file. Quads and other polygons are triangulated,
+// and negative indices are resolved to global indices. Material libraries named by mtllib
+// statements are decoded from the readers returned by the open function and returned by name
+// along with the objects, including the ones no object uses. Groups and smoothing groups are
+// ignored, since each object is drawn as one mesh with flat or given normals.
+func decodeObjs(r io.Reader, open func(name string) (io.ReadCloser, error)) ([]*obj, map[string]*objMaterial, error) {
 	var allObjs []*obj
 	var currentObj *obj
+	var counts objCounts
+	materials := map[string]*objMaterial{}
+
+	// decodeLine decodes a line and returns an error without the line's context.
+	decodeLine := func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			return nil
+		}
 
-	sc := bufio.NewScanner(r)
-	for sc.Scan() {
-		line := strings.TrimSpace(sc.Text())
-		switch {
-		case strings.HasPrefix(line, "o "):
+		// Check that there is an object for statements that add to one.
+		switch fields[0] {
+		case "v", "vt", "vn", "f", "usemtl":
+			if currentObj == nil {
+				return errors.New("missing object ID")
+			}
+		}
+
+		switch fields[0] {
+		case "o":
 			o, err := decodeObjObject(line)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			currentObj = o
 			allObjs = append(allObjs, o)
 
-		case strings.HasPrefix(line, "v "):
+		case "v":
 			v, err := decodeObjVertex(line)
 			if err != nil {
-				return nil, err
-			}
-			if currentObj == nil {
-				return nil, errors.New("missing object ID")
+				return err
 			}
 			currentObj.vertices = append(currentObj.vertices, v)
+			counts.vertices++
 
-		case strings.HasPrefix(line, "vt "):
+		case "vt":
 			tc, err := decodeObjTexCoord(line)
 			if err != nil {
-				return nil, err
-			}
-			if currentObj == nil {
-				return nil, errors.New("missing object ID")
+				return err
 			}
 			currentObj.texCoords = append(currentObj.texCoords, tc)
+			counts.texCoords++
 
-		case strings.HasPrefix(line, "vn "):
+		case "vn":
 			n, err := decodeObjNormal(line)
 			if err != nil {
-				return nil, err
-			}
-			if currentObj == nil {
-				return nil, errors.New("missing object ID")
+				return err
 			}
 			currentObj.normals = append(currentObj.normals, n)
+			counts.normals++
 
-		case strings.HasPrefix(line, "f "):
-			f, err := decodeObjFace(line)
+		case "f":
+			fs, err := decodeObjFace(line, counts)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			if currentObj == nil {
-				return nil, errors.New("missing object ID")
+			currentObj.faces = append(currentObj.faces, fs...)
+
+		case "mtllib":
+			if len(fields) < 2 {
+				return errors.New("missing material library name")
+			}
+			if open == nil {
+				return fmt.Errorf("cannot open material library: %s", fields[1])
+			}
+			for _, name := range fields[1:] {
+				mr, err := open(name)
+				if err != nil {
+					return err
+				}
+				ms, err := decodeMtl(mr)
+				mr.Close()
+				if err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+				for _, m := range ms {
+					materials[m.name] = m
+				}
+			}
+
+		case "usemtl":
+			if len(fields) != 2 {
+				return errors.New("usemtl should have a material name")
+			}
+			m, ok := materials[fields[1]]
+			if !ok {
+				return fmt.Errorf("material not found: %s", fields[1])
+			}
+			currentObj.materialUses = append(currentObj.materialUses, &objMaterialUse{
+				material:  m,
+				faceIndex: len(currentObj.faces),
+			})
+		}
+		return nil
+	}
+
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		if err := decodeLine(strings.TrimSpace(sc.Text())); err != nil {
+			if currentObj != nil {
+				return nil, nil, fmt.Errorf("line %d: object %s: %v", lineNum, currentObj.id, err)
 			}
-			currentObj.faces = append(currentObj.faces, f)
+			return nil, nil, fmt.Errorf("line %d: %v", lineNum, err)
 		}
 	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
 
-	return allObjs, nil
+	return allObjs, materials, nil
 }
 
 func decodeObjObject(line string) (*obj, error) {
@@ -146,54 +226,70 @@ func decodeObjNormal(line string) (*objNormal, error) {
 	return n, nil
 }
 
-func decodeObjFace(line string) (*objFace, error) {
-	f := &objFace{}
-
-	var specs [numFaceElements]string
-	if _, err := fmt.Sscanf(line, "f %s %s %s", &specs[0], &specs[1], &specs[2]); err != nil {
-		return nil, err
+// decodeObjFace decodes a face with three or more elements into triangles that fan out
+// from its first element. Negative indices are resolved relative to the counts.
+func decodeObjFace(line string, counts objCounts) ([]*objFace, error) {
+	specs := strings.Fields(line)[1:]
+	if len(specs) < numFaceElements {
+		return nil, fmt.Errorf("face should have at least %d elements: %d", numFaceElements, len(specs))
 	}
 
-	var err error
-	makeElement := func(spec string) (objFaceElement, error) {
-		tokens := strings.Split(spec, "/")
-		if len(tokens) == 0 {
-			return objFaceElement{}, errors.New("face has no elements")
+	// resolveIndex returns the global index of the token or 0 if the token is empty and optional.
+	resolveIndex := func(token, kind string, count int, optional bool) (int, error) {
+		if token == "" && optional {
+			return 0, nil
 		}
 
-		e := objFaceElement{}
-
-		e.vertexIndex, err = strconv.Atoi(tokens[0])
+		i, err := strconv.Atoi(token)
 		if err != nil {
-			return objFaceElement{}, err
+			return 0, fmt.Errorf("bad %s index: %q", kind, token)
 		}
 
-		if len(tokens) < 2 {
-			return e, nil
+		// Negative indices count back from the last one so far, so -1 is the last one.
+		if i < 0 {
+			i += count + 1
 		}
-
-		e.texCoordIndex, err = strconv.Atoi(tokens[1])
-		if err != nil {
-			return objFaceElement{}, err
+		if i < 1 || i > count {
+			return 0, fmt.Errorf("%s index out of range: %s", kind, token)
 		}
+		return i, nil
+	}
 
-		if len(tokens) < 3 {
-			return e, nil
+	makeElement := func(spec string) (objFaceElement, error) {
+		tokens := strings.Split(spec, "/")
+		if len(tokens) > 3 {
+			return objFaceElement{}, fmt.Errorf("bad face element: %q", spec)
+		}
+		for len(tokens) < 3 {
+			tokens = append(tokens, "")
 		}
 
-		e.normalIndex, err = strconv.Atoi(tokens[2])
-		if err != nil {
+		var e objFaceElement
+		var err error
+		if e.vertexIndex, err = resolveIndex(tokens[0], "vertex", counts.vertices, false); err != nil {
+			return objFaceElement{}, err
+		}
+		if e.texCoordIndex, err = resolveIndex(tokens[1], "texture coordinate", counts.texCoords, true); err != nil {
+			return objFaceElement{}, err
+		}
+		if e.normalIndex, err = resolveIndex(tokens[2], "normal", counts.normals, true); err != nil {
 			return objFaceElement{}, err
 		}
-
 		return e, nil
 	}
 
-	for i, s := range specs {
-		if f[i], err = makeElement(s); err != nil {
+	var elements []objFaceElement
+	for _, s := range specs {
+		e, err := makeElement(s)
+		if err != nil {
 			return nil, err
 		}
+		elements = append(elements, e)
 	}
 
-	return f, nil
+	var faces []*objFace
+	for i := 1; i < len(elements)-1; i++ {
+		faces = append(faces, &objFace{elements[0], elements[i], elements[i+1]})
+	}
+	return faces, nil
 }
@@ -2,7 +2,11 @@ package renderer
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -160,10 +164,223 @@ func TestDecodeObjs(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "quad and pentagon are triangulated",
+			input: `
+				o Pentagon
+				v 0 0 0
+				v 1 0 0
+				v 1 1 0
+				v 0 1 0
+				v -1 0.5 0
+				f 1 2 3 4
+				f 1 2 3 4 5
+			`,
+			want: []*obj{
+				{
+					id: "Pentagon",
+					vertices: []*objVertex{
+						{0, 0, 0},
+						{1, 0, 0},
+						{1, 1, 0},
+						{0, 1, 0},
+						{-1, 0.5, 0},
+					},
+					faces: []*objFace{
+						{{1, 0, 0}, {2, 0, 0}, {3, 0, 0}},
+						{{1, 0, 0}, {3, 0, 0}, {4, 0, 0}},
+						{{1, 0, 0}, {2, 0, 0}, {3, 0, 0}},
+						{{1, 0, 0}, {3, 0, 0}, {4, 0, 0}},
+						{{1, 0, 0}, {4, 0, 0}, {5, 0, 0}},
+					},
+				},
+			},
+		},
+		{
+			desc: "negative indices are relative to the vertices so far in the file",
+			input: `
+				o First
+				v 0 0 0
+				v 1 0 0
+				v 1 1 0
+				o Second
+				v 0 0 1
+				v 1 0 1
+				v 1 1 1
+				vt 0 0
+				vn 0 0 1
+				f -3/-1/-1 -2//-1 -1/-1
+			`,
+			want: []*obj{
+				{
+					id: "First",
+					vertices: []*objVertex{
+						{0, 0, 0},
+						{1, 0, 0},
+						{1, 1, 0},
+					},
+				},
+				{
+					id: "Second",
+					vertices: []*objVertex{
+						{0, 0, 1},
+						{1, 0, 1},
+						{1, 1, 1},
+					},
+					texCoords: []*objTexCoord{
+						{0, 0},
+					},
+					normals: []*objNormal{
+						{0, 0, 1},
+					},
+					faces: []*objFace{
+						{{4, 1, 1}, {5, 0, 1}, {6, 1, 0}},
+					},
+				},
+			},
+		},
+		{
+			desc: "groups, smoothing groups, and comments are ignored",
+			input: `
+				o Triangle
+				g side
+				s 1
+				v 0 0 0
+				v 1 0 0
+				# comment
+				v 1 1 0
+				s off
+				f 1 2 3
+			`,
+			want: []*obj{
+				{
+					id: "Triangle",
+					vertices: []*objVertex{
+						{0, 0, 0},
+						{1, 0, 0},
+						{1, 1, 0},
+					},
+					faces: []*objFace{
+						{{1, 0, 0}, {2, 0, 0}, {3, 0, 0}},
+					},
+				},
+			},
+		},
+		{
+			desc: "vertex index out of range",
+			input: `
+				o Triangle
+				v 0 0 0
+				v 1 0 0
+				f 1 2 3
+			`,
+			wantErr: errors.New("line 5: object Triangle: vertex index out of range: 3"),
+		},
+		{
+			desc: "negative index before the first vertex",
+			input: `
+				o Triangle
+				v 0 0 0
+				v 1 0 0
+				v 1 1 0
+				f 1 2 -4
+			`,
+			wantErr: errors.New("line 6: object Triangle: vertex index out of range: -4"),
+		},
+		{
+			desc: "face with too few elements",
+			input: `
+				o Line
+				v 0 0 0
+				v 1 0 0
+				f 1 2
+			`,
+			wantErr: errors.New("line 5: object Line: face should have at least 3 elements: 2"),
+		},
+		{
+			desc: "bad vertex",
+			input: `
+				o Point
+				v 0 zero 0
+			`,
+			wantErr: errors.New("line 3: object Point:"),
+		},
+		{
+			desc: "material library without a way to open it",
+			input: `
+				mtllib meshes.mtl
+			`,
+			wantErr: errors.New("line 2: cannot open material library: meshes.mtl"),
+		},
+		{
+			desc: "material that is not in a library",
+			input: `
+				o Triangle
+				usemtl Red
+			`,
+			wantErr: errors.New("line 3: object Triangle: material not found: Red"),
+		},
 	} {
-		got, gotErr := decodeObjs(strings.NewReader(tt.input))
+		got, _, gotErr := decodeObjs(strings.NewReader(tt.input), nil)
 		if !reflect.DeepEqual(got, tt.want) || !errorContains(gotErr, tt.wantErr) {
 			t.Errorf("[%s] decodeObjs(%q) = (%v, %v), want (%v, %v)", tt.desc, tt.input, pp(got), gotErr, pp(tt.want), tt.wantErr)
 		}
 	}
 }
+
+func TestDecodeObjsMaterials(t *testing.T) {
+	files := map[string]string{
+		"colors.mtl": `
+			newmtl Red
+			Kd 1 0 0
+			newmtl Blue
+			Kd 0 0 1
+		`,
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("file not found: %s", name)
+		}
+		return ioutil.NopCloser(strings.NewReader(f)), nil
+	}
+
+	input := `
+		mtllib colors.mtl
+		o Square
+		v 0 0 0
+		v 1 0 0
+		v 1 1 0
+		v 0 1 0
+		usemtl Red
+		f 1 2 3
+		usemtl Blue
+		f 1 3 4
+	`
+	objs, materials, err := decodeObjs(strings.NewReader(input), open)
+	if err != nil {
+		t.Fatalf("decodeObjs(%q) err = %v, want nil", input, err)
+	}
+
+	var got []string
+	for _, u := range objs[0].materialUses {
+		got = append(got, fmt.Sprintf("%s@%d", u.material.name, u.faceIndex))
+	}
+	if want := []string{"Red@0", "Blue@1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeObjs(%q) material uses = %v, want %v", input, got, want)
+	}
+
+	got = nil
+	for name := range materials {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if want := []string{"Blue", "Red"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeObjs(%q) materials = %v, want %v", input, got, want)
+	}
+
+	input = "mtllib missing.mtl\n"
+	if _, _, err := decodeObjs(strings.NewReader(input), open); !errorContains(err, errors.New("line 1: file not found: missing.mtl")) {
+		t.Errorf("decodeObjs(%q) err = %v, want file not found", input, err)
+	}
+}
@@ -0,0 +1,345 @@
+package renderer
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+const (
+	// maxParticles is the most particles that can be alive at once.
+	// Emitters spawn no more particles past it, so huge chains cannot slow down the game.
+	maxParticles = 1024
+
+	// maxBurstChainLevel is the chain level with the biggest bursts.
+	maxBurstChainLevel = 4
+
+	// emitterUpdates is how many updates an emitter spreads its burst over.
+	emitterUpdates = 3
+
+	// particleSeed seeds the random numbers of each board's particles,
+	// so the same explosions always spawn the same particles.
+	particleSeed = 1
+)
+
+// particleConfig describes the particles that an emitter spawns.
+type particleConfig struct {
+	// count is how many particles a burst has at chain level 0.
+	count int
+
+	// chainCount is how many more particles a burst has with each chain level.
+	chainCount int
+
+	// minSpeed and maxSpeed are the range of the particles' starting speeds in units per second.
+	minSpeed, maxSpeed float32
+
+	// minLifetime and maxLifetime are the range of how long the particles live in seconds.
+	minLifetime, maxLifetime float32
+
+	// minScale and maxScale are the range of the particles' sizes relative to their meshes.
+	minScale, maxScale float32
+
+	// gravity is how fast the particles fall in units per second squared.
+	gravity float32
+
+	// maxSpin is how fast the particles can tumble in radians per second.
+	maxSpin float32
+
+	// brightness is how much brighter the particles are drawn from 0.
+	brightness float32
+}
+
+var (
+	// sparkConfig describes the small bright copies of an exploding block that shoot out of it.
+	sparkConfig = &particleConfig{
+		count:       8,
+		chainCount:  6,
+		minSpeed:    3,
+		maxSpeed:    7,
+		minLifetime: 0.25,
+		maxLifetime: 0.5,
+		minScale:    0.1,
+		maxScale:    0.2,
+		gravity:     4,
+		brightness:  1,
+	}
+
+	// debrisConfig describes the pieces of an exploding block that tumble down as its fragment meshes.
+	debrisConfig = &particleConfig{
+		count:       4,
+		chainCount:  2,
+		minSpeed:    1.5,
+		maxSpeed:    3.5,
+		minLifetime: 0.6,
+		maxLifetime: 1,
+		minScale:    0.3,
+		maxScale:    0.5,
+		gravity:     12,
+		maxSpin:     4 * math.Pi,
+	}
+)
+
+// particleEmitter spawns a burst of particles at a cell over a few updates.
+type particleEmitter struct {
+	// config describes the particles to spawn.
+	config *particleConfig
+
+	// x and y are the cell's column and ring.
+	x, y int
+
+	// meshIDs are the meshes that the particles are randomly drawn as.
+	meshIDs []string
+
+	// remaining is how many particles are left to spawn.
+	remaining int
+
+	// perUpdate is how many particles to spawn each update.
+	perUpdate int
+}
+
+// particle is a mesh that flies out of a cell, falls, and fades out.
+type particle struct {
+	// config describes how the particle is drawn.
+	config *particleConfig
+
+	// meshID is the ID of the mesh to draw.
+	meshID string
+
+	// x and y are the column and ring of the cell that the particle flies out of.
+	x, y int
+
+	// position is the particle's position relative to the cell after the last update.
+	position vector3
+
+	// prevPosition is the particle's position before the last update to interpolate from.
+	prevPosition vector3
+
+	// velocity is the particle's velocity in units per second.
+	velocity vector3
+
+	// spinAxis is the axis that the particle tumbles around.
+	spinAxis vector3
+
+	// spin is how fast the particle tumbles in radians per second.
+	spin float32
+
+	// scale is the particle's size relative to its mesh.
+	scale float32
+
+	// age is how many updates the particle has been alive.
+	age int
+
+	// lifetime is how many updates the particle lives.
+	lifetime int
+}
+
+// particleSystem spawns and simulates the particles of a board on the game's fixed update step,
+// so that the same game always has the same particles no matter the frame rate.
+type particleSystem struct {
+	// board is the board that the particles are on.
+	board *game.Board
+
+	// topRing is the board's top ring, which changes when the board trims a ring as it rises.
+	topRing *game.Ring
+
+	// rand is the random number generator of the board's particles.
+	rand *rand.Rand
+
+	// clears are the EventClear events to spawn bursts for on the next update.
+	clears []game.Event
+
+	// emitters are the emitters that are still spawning particles.
+	emitters []*particleEmitter
+
+	// alive are the particles in the order they were spawned.
+	alive []*particle
+}
+
+// particles are the particles of the current board.
+var particles = &particleSystem{}
+
+// HandleEvent spawns a burst of particles for each block that explodes on the next Update.
+// Pass it to game.Subscribe.
+func HandleEvent(e game.Event) {
+	particles.handleEvent(e)
+}
+
+// Update spawns and moves the particles by one fixed update step. Call it after each game update.
+func Update(g *game.Game) {
+	particles.update(g)
+}
+
+// handleEvent remembers the blocks that explode to spawn bursts for them on the next update.
+func (s *particleSystem) handleEvent(e game.Event) {
+	if e.Type == game.EventClear {
+		s.clears = append(s.clears, e)
+	}
+}
+
+// update spawns bursts for the exploding blocks, spawns the emitters' particles,
+// and moves the particles, removing the ones whose lifetimes are over.
+// Particles only move while the game is playing, like the board's blocks.
+func (s *particleSystem) update(g *game.Game) {
+	clears := s.clears
+	s.clears = nil
+
+	b := g.Board
+	if b != s.board {
+		*s = particleSystem{
+			board: b,
+			rand:  rand.New(rand.NewSource(particleSeed)),
+		}
+		if b != nil && len(b.Rings) > 0 {
+			s.topRing = b.Rings[0]
+		}
+	}
+
+	if b == nil || g.State != game.GamePlaying {
+		return
+	}
+
+	// Move the particles up a ring when the board trims its top ring, so they stay with their cells.
+	if len(b.Rings) > 0 && b.Rings[0] != s.topRing {
+		for _, e := range s.emitters {
+			e.y--
+		}
+		for _, p := range s.alive {
+			p.y--
+		}
+		s.topRing = b.Rings[0]
+	}
+
+	for _, e := range clears {
+		if e.Y < 0 || e.Y >= len(b.Rings) || e.X < 0 || e.X >= len(b.Rings[e.Y].Cells) {
+			continue
+		}
+
+		level := e.ChainLevel
+		if level > maxBurstChainLevel {
+			level = maxBurstChainLevel
+		}
+
+		block := b.Rings[e.Y].Cells[e.X].Block
+		fragments := blockFragmentMeshIDs(block)
+		s.addEmitter(sparkConfig, e.X, e.Y, []string{blockMeshID(block)}, level)
+		s.addEmitter(debrisConfig, e.X, e.Y, fragments[:], level)
+	}
+
+	// Spawn before moving, so new particles move on the update they are spawned.
+	emitters := s.emitters[:0]
+	for _, e := range s.emitters {
+		n := e.perUpdate
+		if n > e.remaining {
+			n = e.remaining
+		}
+		e.remaining -= n
+
+		for ; n > 0 && len(s.alive) < maxParticles; n-- {
+			s.alive = append(s.alive, s.spawn(e))
+		}
+
+		if e.remaining > 0 {
+			emitters = append(emitters, e)
+		}
+	}
+	s.emitters = emitters
+
+	const dt = game.SecPerUpdate
+	alive := s.alive[:0]
+	for _, p := range s.alive {
+		p.prevPosition = p.position
+		p.velocity.y -= p.config.gravity * dt
+		p.position.x += p.velocity.x * dt
+		p.position.y += p.velocity.y * dt
+		p.position.z += p.velocity.z * dt
+
+		if p.age++; p.age < p.lifetime {
+			alive = append(alive, p)
+		}
+	}
+	s.alive = alive
+}
+
+// addEmitter adds an emitter for a burst that is bigger with each chain level.
+func (s *particleSystem) addEmitter(c *particleConfig, x, y int, meshIDs []string, chainLevel int) {
+	count := c.count + c.chainCount*chainLevel
+	s.emitters = append(s.emitters, &particleEmitter{
+		config:    c,
+		x:         x,
+		y:         y,
+		meshIDs:   meshIDs,
+		remaining: count,
+		perUpdate: (count + emitterUpdates - 1) / emitterUpdates,
+	})
+}
+
+// spawn returns a new particle from the emitter's cell with a random mesh, direction, speed,
+// size, spin, and lifetime within the emitter's config.
+func (s *particleSystem) spawn(e *particleEmitter) *particle {
+	c := e.config
+	between := func(min, max float32) float32 {
+		return min + (max-min)*s.rand.Float32()
+	}
+
+	// Fly out of the board's surface, mostly sideways and up.
+	dir := vector3{between(-1, 1), between(-0.25, 1), between(0, 1)}.normalize()
+	speed := between(c.minSpeed, c.maxSpeed)
+
+	// Start anywhere within the block, which is 2 units on each side.
+	position := vector3{between(-1, 1), between(-1, 1), between(-1, 1)}
+
+	lifetime := int(between(c.minLifetime, c.maxLifetime) / game.SecPerUpdate)
+	if lifetime < 1 {
+		lifetime = 1
+	}
+
+	p := &particle{
+		config:       c,
+		meshID:       e.meshIDs[s.rand.Intn(len(e.meshIDs))],
+		x:            e.x,
+		y:            e.y,
+		position:     position,
+		prevPosition: position,
+		velocity:     vector3{dir.x * speed, dir.y * speed, dir.z * speed},
+		scale:        between(c.minScale, c.maxScale),
+		lifetime:     lifetime,
+	}
+	if c.maxSpin > 0 {
+		p.spinAxis = vector3{between(-1, 1), between(-1, 1), between(-1, 1)}.normalize()
+		p.spin = between(-c.maxSpin, c.maxSpin)
+	}
+	return p
+}
+
+// drawParticles adds commands to draw the board's particles at the fudge between updates.
+// They fade out over their lifetimes.
+func (l *drawList) drawParticles(metrics *metrics) {
+	if particles.board != metrics.b {
+		return
+	}
+
+	for _, p := range particles.alive {
+		age := float32(p.age) + metrics.fudge
+		t := age / float32(p.lifetime)
+		if t > 1 {
+			t = 1
+		}
+
+		l.state.brightness = p.config.brightness
+		l.state.alpha = linear(t, 1, -1)
+
+		x := linear(metrics.fudge, p.prevPosition.x, p.position.x-p.prevPosition.x)
+		y := linear(metrics.fudge, p.prevPosition.y, p.position.y-p.prevPosition.y)
+		z := linear(metrics.fudge, p.prevPosition.z, p.position.z-p.prevPosition.z)
+
+		m := newScaleMatrix(p.scale, p.scale, p.scale)
+		if p.spin != 0 {
+			q := newAxisAngleQuaternion(p.spinAxis, p.spin*age*game.SecPerUpdate)
+			m = m.mult(newQuaternionMatrix(q.normalize()))
+		}
+		m = m.mult(newTranslationMatrix(x, y, z))
+		m = m.mult(metrics.cellMatrix(float32(p.x), float32(p.y)))
+		l.draw(p.meshID, m)
+	}
+}
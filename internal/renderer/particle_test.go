@@ -0,0 +1,132 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+func TestParticleSystemBursts(t *testing.T) {
+	for _, tt := range []struct {
+		desc       string
+		chainLevel int
+		want       int
+	}{
+		{
+			desc: "burst without a chain",
+			want: sparkConfig.count + debrisConfig.count,
+		},
+		{
+			desc:       "bigger burst for a higher chain level",
+			chainLevel: 2,
+			want:       sparkConfig.count + debrisConfig.count + 2*(sparkConfig.chainCount+debrisConfig.chainCount),
+		},
+		{
+			desc:       "chain levels past the biggest burst",
+			chainLevel: maxBurstChainLevel + 10,
+			want:       sparkConfig.count + debrisConfig.count + maxBurstChainLevel*(sparkConfig.chainCount+debrisConfig.chainCount),
+		},
+	} {
+		g := newTestGame(game.GamePlaying, newTestBoard(game.BoardLive))
+		s := &particleSystem{}
+		s.handleEvent(game.Event{Type: game.EventClear, X: 3, Y: 0, ChainLevel: tt.chainLevel})
+		for i := 0; i < emitterUpdates; i++ {
+			s.update(g)
+		}
+
+		if got := len(s.alive); got != tt.want {
+			t.Errorf("[%s] particles = %d, want %d", tt.desc, got, tt.want)
+		}
+		if got := len(s.emitters); got != 0 {
+			t.Errorf("[%s] emitters = %d, want 0", tt.desc, got)
+		}
+	}
+}
+
+func TestParticleSystemUpdate(t *testing.T) {
+	g := newTestGame(game.GamePlaying, newTestBoard(game.BoardLive))
+	s := &particleSystem{}
+	s.handleEvent(game.Event{Type: game.EventClear, X: 3, Y: 0})
+	s.update(g)
+
+	// Fragments of the exploding yellow block fly out as debris along with yellow sparks.
+	meshIDs := map[string]bool{}
+	for _, p := range s.alive {
+		meshIDs[p.meshID] = true
+	}
+	for id := range meshIDs {
+		if id != "yellow" && id != "yellow_north_west" && id != "yellow_north_east" && id != "yellow_south_east" && id != "yellow_south_west" {
+			t.Errorf("particle mesh ID = %q, want a yellow block or fragment", id)
+		}
+	}
+
+	p := s.alive[0]
+	prev := *p
+	s.update(g)
+	if p.prevPosition != prev.position {
+		t.Errorf("prevPosition = %v, want %v", p.prevPosition, prev.position)
+	}
+	if want := prev.velocity.y - p.config.gravity*game.SecPerUpdate; p.velocity.y != want {
+		t.Errorf("velocity.y = %v, want %v after gravity", p.velocity.y, want)
+	}
+
+	// Particles do not move while the game is paused.
+	g.State = game.GamePaused
+	paused := *p
+	s.update(g)
+	if *p != paused {
+		t.Errorf("paused particle = %+v, want %+v", *p, paused)
+	}
+
+	// Every particle is removed once its lifetime is over.
+	g.State = game.GamePlaying
+	maxUpdates := int(debrisConfig.maxLifetime/game.SecPerUpdate) + emitterUpdates
+	for i := 0; i < maxUpdates; i++ {
+		s.update(g)
+	}
+	if got := len(s.alive); got != 0 {
+		t.Errorf("particles = %d, want 0 after their lifetimes", got)
+	}
+}
+
+func TestParticleSystemDeterministic(t *testing.T) {
+	// run returns the particles after the same explosions on a new board.
+	run := func() []particle {
+		g := newTestGame(game.GamePlaying, newTestBoard(game.BoardLive))
+		s := &particleSystem{}
+		for i := 0; i < 10; i++ {
+			if i%3 == 0 {
+				s.handleEvent(game.Event{Type: game.EventClear, X: i % 4, Y: 0, ChainLevel: i / 3})
+			}
+			s.update(g)
+		}
+
+		var ps []particle
+		for _, p := range s.alive {
+			ps = append(ps, *p)
+		}
+		return ps
+	}
+
+	got, want := run(), run()
+	if len(got) == 0 {
+		t.Fatalf("particles = 0, want some")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("particles differ between runs:\n%+v\n%+v", got, want)
+	}
+}
+
+func TestParticleSystemNewBoard(t *testing.T) {
+	g := newTestGame(game.GamePlaying, newTestBoard(game.BoardLive))
+	s := &particleSystem{}
+	s.handleEvent(game.Event{Type: game.EventClear, X: 3, Y: 0})
+	s.update(g)
+
+	g.Board = newTestBoard(game.BoardLive)
+	s.update(g)
+	if got := len(s.alive); got != 0 {
+		t.Errorf("particles = %d, want 0 on a new board", got)
+	}
+}
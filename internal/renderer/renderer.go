@@ -7,15 +7,17 @@ import (
 	"image/color"
 	"image/draw"
 	"image/png"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
+	"sort"
 
 	"github.com/btmura/blockcillin/internal/asset"
 	"github.com/btmura/blockcillin/internal/game"
+	"github.com/btmura/blockcillin/internal/locale"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
 )
 
 var (
@@ -28,6 +30,9 @@ var (
 	directionalLightColor = [3]float32{0.5, 0.5, 0.5}
 	directionalVector     = [3]float32{0.5, 0.5, 0.5}
 	blackColor            = [3]float32{}
+
+	viewMatrix   = newViewMatrix(cameraPosition, targetPosition, up)
+	normalMatrix = viewMatrix.inverse().transpose()
 )
 
 var (
@@ -39,11 +44,16 @@ var (
 	directionalLightColorUniform int32
 	directionalVectorUniform     int32
 	textureUniform               int32
+	texCoordRectUniform          int32
 	grayscaleUniform             int32
 	brightnessUniform            int32
 	alphaUniform                 int32
 	mixColorUniform              int32
 	mixAmountUniform             int32
+	diffuseColorUniform          int32
+	specularColorUniform         int32
+	emissiveColorUniform         int32
+	shininessUniform             int32
 )
 
 var (
@@ -64,40 +74,19 @@ var (
 )
 
 var (
-	selectorMesh   *mesh
-	squareMesh     *mesh
-	textLineMesh   *mesh
-	blockMeshes    = map[game.BlockColor]*mesh{}
-	fragmentMeshes = map[game.BlockColor][4]*mesh{}
+	// meshes maps mesh ID to the meshes that draw commands refer to.
+	meshes = map[string]*mesh{}
+
+	// textures maps texture ID to the GL textures that draw commands refer to.
+	// Text is drawn with the glyph atlases' textures, which are added as they are first drawn.
+	textures = map[string]uint32{}
 )
 
 var (
-	menuTitleFontSize  = 54
-	menuTitleTextColor = color.White
-
-	menuItemFontSize  = 36
-	menuItemTextColor = color.Gray{100}
-
-	hudFontSize  = 20
-	hudTextColor = color.White
-
-	markerFontSize  = 36
-	markerTextColor = color.White
-
-	boardTexture uint32
-
-	menuTitleText  = map[game.MenuID]*renderableText{}
-	menuItemText   = map[game.MenuItemID]*renderableText{}
-	menuChoiceText = map[game.MenuChoiceID]*renderableText{}
-	hudItemText    [len(game.HUDItemText)]*renderableText
-
-	menuRuneStrs   = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
-	hudRuneStrs    = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", ":"}
-	markerRuneStrs = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "x"}
-
-	menuRuneText   = map[rune]*renderableText{}
-	hudRuneText    = map[rune]*renderableText{}
-	markerRuneText = map[rune]*renderableText{}
+	menuTitleTextStyle = textStyle{size: 54, color: color.White}
+	menuItemTextStyle  = textStyle{size: 36, color: color.Gray{100}}
+	hudTextStyle       = textStyle{bold: true, size: 20, color: color.White}
+	markerTextStyle    = textStyle{bold: true, size: 36, color: color.White}
 )
 
 func Init() error {
@@ -107,54 +96,16 @@ func Init() error {
 
 	log.Printf("OpenGL version: %s", gl.GoStr(gl.GetString(gl.VERSION)))
 
-	vs, err := asset.String("shader.vert")
-	if err != nil {
-		return err
-	}
-
-	fs, err := asset.String("shader.frag")
-	if err != nil {
-		return err
-	}
-
-	program, err := createProgram(vs, fs)
-	if err != nil {
-		return err
-	}
-	gl.UseProgram(program)
-
-	var shaderErr error
-	uniform := func(name string) int32 {
-		var loc int32
-		loc, shaderErr = getUniformLocation(program, name)
-		return loc
-	}
-
-	projectionViewMatrixUniform = uniform("u_projectionViewMatrix")
-	modelMatrixUniform = uniform("u_modelMatrix")
-	normalMatrixUniform = uniform("u_normalMatrix")
-	ambientLightColorUniform = uniform("u_ambientLightColor")
-	directionalLightColorUniform = uniform("u_directionalLightColor")
-	directionalVectorUniform = uniform("u_directionalVector")
-	textureUniform = uniform("u_texture")
-	grayscaleUniform = uniform("u_grayscale")
-	brightnessUniform = uniform("u_brightness")
-	alphaUniform = uniform("u_alpha")
-	mixColorUniform = uniform("u_mixColor")
-	mixAmountUniform = uniform("u_mixAmount")
-
-	if shaderErr != nil {
-		return shaderErr
+	if err := loadProgram(asset.String); err != nil {
+		// Fall back to the shaders built into the binary, so that a broken shader in
+		// the development directory or a theme is shown on screen instead of exiting.
+		if builtInErr := loadProgram(builtInAssetString); builtInErr != nil {
+			return err
+		}
+		log.Printf("loadProgram: %v", err)
+		programErr = err
 	}
 
-	vm := newViewMatrix(cameraPosition, targetPosition, up)
-	nm := vm.inverse().transpose()
-	gl.UniformMatrix4fv(normalMatrixUniform, 1, false, &nm[0])
-
-	gl.Uniform3fv(ambientLightColorUniform, 1, &ambientLightColor[0])
-	gl.Uniform3fv(directionalLightColorUniform, 1, &directionalLightColor[0])
-	gl.Uniform3fv(directionalVectorUniform, 1, &directionalVector[0])
-
 	SizeCallback = func(width, height int) {
 		if winWidth == width && winHeight == height {
 			return
@@ -163,24 +114,16 @@ func Init() error {
 		log.Printf("window size changed (%dx%d -> %dx%d)", int(winWidth), int(winHeight), width, height)
 		gl.Viewport(0, 0, int32(width), int32(height))
 
-		// Calculate new perspective projection view matrix.
 		winWidth, winHeight = width, height
-		fw, fh := float32(width), float32(height)
-		aspect := fw / fh
-		fovRadians := float32(math.Pi) / 3
-		perspectiveProjectionViewMatrix = vm.mult(newPerspectiveMatrix(fovRadians, aspect, 1, 2000))
-
-		// Calculate new ortho projection view matrix.
-		orthoProjectionViewMatrix = newOrthoMatrix(fw, fh, fw /* use width as depth */)
+		perspectiveProjectionViewMatrix, orthoProjectionViewMatrix = newProjectionViewMatrices(width, height)
 	}
 
-	if err := initMeshes(); err != nil {
+	if err := initAssets(createMeshes, addGLTexture); err != nil {
 		return err
 	}
 
-	if err := initTextures(); err != nil {
-		return err
-	}
+	// Textures are bound to the first texture unit as they are drawn.
+	gl.ActiveTexture(gl.TEXTURE0)
 
 	gl.Enable(gl.CULL_FACE)
 	gl.CullFace(gl.BACK)
@@ -195,144 +138,330 @@ func Init() error {
 	return nil
 }
 
-func initMeshes() error {
-	r, err := asset.Reader("meshes.obj")
+// loadProgram compiles the shaders loaded with the load function into a program,
+// gets its uniforms' locations, and uses it instead of the current program.
+// It keeps the current program if the shaders cannot be loaded, compiled, or linked.
+func loadProgram(load func(name string) (string, error)) error {
+	vs, err := load(vertexShaderAssetName)
 	if err != nil {
 		return err
 	}
 
-	objs, err := decodeObjs(r)
+	fs, err := load(fragmentShaderAssetName)
 	if err != nil {
 		return err
 	}
 
-	meshes := createMeshes(objs)
-	meshMap := map[string]*mesh{}
-	for i, m := range meshes {
-		log.Printf("mesh %d: %s", i, m.id)
-		meshMap[m.id] = m
+	p, err := createProgram(vs, fs)
+	if err != nil {
+		return err
 	}
 
-	mm := func(id string) *mesh {
-		if err != nil {
-			return nil
+	uniforms := []struct {
+		loc  *int32
+		name string
+	}{
+		{&projectionViewMatrixUniform, "u_projectionViewMatrix"},
+		{&modelMatrixUniform, "u_modelMatrix"},
+		{&normalMatrixUniform, "u_normalMatrix"},
+		{&ambientLightColorUniform, "u_ambientLightColor"},
+		{&directionalLightColorUniform, "u_directionalLightColor"},
+		{&directionalVectorUniform, "u_directionalVector"},
+		{&textureUniform, "u_texture"},
+		{&texCoordRectUniform, "u_texCoordRect"},
+		{&grayscaleUniform, "u_grayscale"},
+		{&brightnessUniform, "u_brightness"},
+		{&alphaUniform, "u_alpha"},
+		{&mixColorUniform, "u_mixColor"},
+		{&mixAmountUniform, "u_mixAmount"},
+		{&diffuseColorUniform, "u_diffuseColor"},
+		{&specularColorUniform, "u_specularColor"},
+		{&emissiveColorUniform, "u_emissiveColor"},
+		{&shininessUniform, "u_shininess"},
+	}
+
+	// Get every location before changing any, so the current program keeps working on error.
+	locs := make([]int32, len(uniforms))
+	for i, u := range uniforms {
+		if locs[i], err = getUniformLocation(p, u.name); err != nil {
+			gl.DeleteProgram(p)
+			return err
 		}
-		m, ok := meshMap[id]
-		if !ok {
-			err = fmt.Errorf("mesh not found: %s", id)
-			return nil
-		}
-		return m
 	}
 
-	colorObjIDs := map[game.BlockColor]string{
-		game.Red:    "red",
-		game.Purple: "purple",
-		game.Blue:   "blue",
-		game.Cyan:   "cyan",
-		game.Green:  "green",
-		game.Yellow: "yellow",
+	if program != 0 {
+		gl.DeleteProgram(program)
+	}
+	program = p
+	gl.UseProgram(program)
+	for i, u := range uniforms {
+		*u.loc = locs[i]
 	}
 
-	selectorMesh = mm("selector")
-	squareMesh = mm("square")
-	textLineMesh = mm("text_line")
+	gl.UniformMatrix4fv(normalMatrixUniform, 1, false, &normalMatrix[0])
 
-	for c, id := range colorObjIDs {
-		blockMeshes[c] = mm(id)
-		fragmentMeshes[c] = [4]*mesh{
-			mm(id + "_north_west"),
-			mm(id + "_north_east"),
-			mm(id + "_south_east"),
-			mm(id + "_south_west"),
-		}
+	gl.Uniform3fv(ambientLightColorUniform, 1, &ambientLightColor[0])
+	gl.Uniform3fv(directionalLightColorUniform, 1, &directionalLightColor[0])
+	gl.Uniform3fv(directionalVectorUniform, 1, &directionalVector[0])
+	gl.Uniform3fv(mixColorUniform, 1, &blackColor[0])
+
+	// Textures are bound to the first texture unit as they are drawn.
+	gl.Uniform1i(textureUniform, 0)
+
+	return nil
+}
+
+// builtInAssetString returns the asset built into the binary as a string
+// without any theme or development directory overriding it.
+func builtInAssetString(name string) (string, error) {
+	b, err := asset.Asset(name)
+	if err != nil {
+		return "", err
 	}
+	return string(b), nil
+}
+
+// newProjectionViewMatrices returns the perspective projection view matrix for the board
+// and the ortho projection view matrix for overlays in a window of the given size.
+func newProjectionViewMatrices(width, height int) (perspective, ortho matrix4) {
+	fw, fh := float32(width), float32(height)
+	aspect := fw / fh
+	fovRadians := float32(math.Pi) / 3
+	perspective = viewMatrix.mult(newPerspectiveMatrix(fovRadians, aspect, 1, 2000))
+	ortho = newOrthoMatrix(fw, fh, fw /* use width as depth */)
+	return
+}
 
+// initAssets loads the meshes, the textures of the board and the meshes' materials, and fonts
+// from the current theme. It makes meshes with the create function and passes textures to the add function.
+func initAssets(create func(objs []*obj) []*mesh, add func(id string, rgba *image.RGBA) error) error {
+	objs, err := initMeshes(create)
 	if err != nil {
 		return err
 	}
+	if err := initTextures(textureIDs(objs), add); err != nil {
+		return err
+	}
+	if err := initFonts(); err != nil {
+		return err
+	}
+	loadedTheme = asset.CurrentTheme()
 	return nil
 }
 
-func initTextures() error {
-	var textureUnit uint32 = gl.TEXTURE0
-	var err error
-
-	boardTexture, err = createAssetTexture(textureUnit, "texture.png")
+// initMeshes decodes the meshes asset, makes meshes from it with the create function,
+// and replaces the meshes map with them. It returns the decoded objects.
+func initMeshes(create func(objs []*obj) []*mesh) ([]*obj, error) {
+	r, err := asset.Reader(meshesAssetName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	textureUnit++
+	defer r.Close()
 
-	plain, err := freetype.ParseFont(asset.MustAsset("CPMono_v07 Plain.ttf"))
+	objs, err := decodeMeshObjs(r, func(name string) (io.ReadCloser, error) {
+		return asset.Reader(name)
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("%s: %v", meshesAssetName, err)
 	}
 
-	bold, err := freetype.ParseFont(asset.MustAsset("CPMono_v07 Bold.ttf"))
+	m := map[string]*mesh{}
+	for i, mm := range create(objs) {
+		log.Printf("mesh %d: %s", i, mm.id)
+		m[mm.id] = mm
+	}
+	meshes = m
+	return objs, nil
+}
+
+// decodeMeshObjs decodes the objects of the meshes asset, adds the objects of the block colors
+// and kinds that only have materials, and checks that it has every mesh draw commands refer to.
+func decodeMeshObjs(r io.Reader, open func(name string) (io.ReadCloser, error)) ([]*obj, error) {
+	objs, materials, err := decodeObjs(r, open)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	makeText := func(text string, font *truetype.Font, size int, color color.Color) (rt *renderableText) {
-		if err != nil {
-			return nil
-		}
-		rt, err = createText(text, size, color, font, textureUnit)
-		textureUnit++
-		return
+	objs = addMaterialBlockObjs(objs, materials)
+	if err := checkMeshIDs(objs); err != nil {
+		return nil, err
 	}
+	return objs, nil
+}
 
-	for id, text := range game.MenuTitleText {
-		menuTitleText[id] = makeText(text, plain, menuTitleFontSize, menuTitleTextColor)
+// checkMeshIDs checks that the objects have every mesh that draw commands can refer to.
+func checkMeshIDs(objs []*obj) error {
+	found := map[string]bool{}
+	for _, o := range objs {
+		found[o.id] = true
 	}
-	for id, text := range game.MenuItemText {
-		menuItemText[id] = makeText(text, plain, menuItemFontSize, menuItemTextColor)
+
+	ids := []string{bombMeshID, selectorMeshID, squareMeshID, textLineMeshID}
+	for _, id := range blockMeshIDs() {
+		f := fragmentMeshIDs(id)
+		ids = append(ids, id)
+		ids = append(ids, f[:]...)
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return fmt.Errorf("mesh not found: %s", id)
+		}
 	}
-	for id, text := range game.MenuChoiceText {
-		menuChoiceText[id] = makeText(text, plain, menuItemFontSize, menuItemTextColor)
+	return nil
+}
+
+// initTextures decodes the texture assets and passes them to the add function with their texture IDs.
+func initTextures(ids []string, add func(id string, rgba *image.RGBA) error) error {
+	for _, id := range ids {
+		r, err := asset.Reader(id)
+		if err != nil {
+			return err
+		}
+
+		rgba, err := decodeImage(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", id, err)
+		}
+		if err := add(id, rgba); err != nil {
+			return err
+		}
 	}
-	for i, v := range game.HUDItemText {
-		hudItemText[i] = makeText(v, bold, hudFontSize, hudTextColor)
+	return nil
+}
+
+// textureIDs returns the sorted IDs of the board texture and the textures of the objects' materials.
+func textureIDs(objs []*obj) []string {
+	found := map[string]bool{boardTextureID: true}
+	for _, o := range objs {
+		for _, u := range o.materialUses {
+			if id := u.material.diffuseMap; id != "" {
+				found[id] = true
+			}
+		}
 	}
-	for _, v := range menuRuneStrs {
-		menuRuneText[[]rune(v)[0]] = makeText(v, plain, menuItemFontSize, menuItemTextColor)
+
+	var ids []string
+	for id := range found {
+		ids = append(ids, id)
 	}
-	for _, v := range hudRuneStrs {
-		hudRuneText[[]rune(v)[0]] = makeText(v, bold, hudFontSize, hudTextColor)
+	sort.Strings(ids)
+	return ids
+}
+
+// addGLTexture uploads the image to a new GL texture and adds it to the textures map.
+func addGLTexture(id string, rgba *image.RGBA) (err error) {
+	textures[id], err = createTexture(rgba)
+	return
+}
+
+// initFonts parses the font assets of the languages' catalogs and adds them to the fonts map.
+// The locale package must be initialized first.
+func initFonts() error {
+	for _, l := range locale.Languages() {
+		for _, key := range []string{locale.PlainFontKey, locale.BoldFontKey} {
+			if err := loadFont(l.Text(key)); err != nil {
+				return err
+			}
+		}
 	}
-	for _, v := range markerRuneStrs {
-		markerRuneText[[]rune(v)[0]] = makeText(v, bold, markerFontSize, markerTextColor)
+	return nil
+}
+
+// loadFont parses the font asset and adds it to the fonts map if it is not there already.
+func loadFont(name string) error {
+	if _, ok := fonts[name]; ok {
+		return nil
 	}
 
+	b, err := asset.CurrentTheme().Asset(name)
 	if err != nil {
 		return err
 	}
-	return nil
-}
 
-func createAssetTexture(textureUnit uint32, name string) (uint32, error) {
-	r, err := asset.Reader(name)
+	f, err := freetype.ParseFont(b)
 	if err != nil {
-		return 0, err
+		return fmt.Errorf("%s: %v", name, err)
 	}
+	fonts[name] = f
+	return nil
+}
 
+// decodeImage decodes the image into an RGBA image.
+func decodeImage(r io.Reader) (*image.RGBA, error) {
 	img, _, err := image.Decode(r)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	rgba := image.NewRGBA(img.Bounds())
 	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
-	return createTexture(textureUnit, rgba)
+	return rgba, nil
 }
 
 func Render(g *game.Game, fudge float32) {
+	// Reload everything for a new theme or only the assets that changed in development mode.
+	if asset.CurrentTheme() != loadedTheme {
+		reloadProgram()
+		reloadGLAssets()
+	} else if names := asset.Changed(); len(names) > 0 {
+		reloadChanged(names)
+	}
+
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-	if renderBoard(g, fudge) {
-		renderHUD(g, fudge)
+
+	// Build the draw list first, since laying out text can draw new glyphs into the atlases.
+	l := newDrawList(g, fudge, winWidth, winHeight)
+	l.drawErrors(programErr, assetsErr)
+	uploadAtlases()
+	executeDrawList(l)
+}
+
+// uploadAtlases uploads the glyph atlases that have new glyphs to their textures.
+func uploadAtlases() {
+	for _, a := range atlases {
+		if !a.dirty {
+			continue
+		}
+		if t, ok := textures[a.id]; ok {
+			updateTexture(t, a.image)
+		} else {
+			t, err := createTexture(a.image)
+			logFatalIfErr("createTexture", err)
+			textures[a.id] = t
+		}
+		a.dirty = false
+	}
+}
+
+// executeDrawList draws the draw list's commands in order with GL.
+func executeDrawList(l *drawList) {
+	for _, c := range l.commands {
+		pv := &perspectiveProjectionViewMatrix
+		if c.pass == drawPassOverlay {
+			pv = &orthoProjectionViewMatrix
+		}
+		gl.UniformMatrix4fv(projectionViewMatrixUniform, 1, false, &pv[0])
+		gl.UniformMatrix4fv(modelMatrixUniform, 1, false, &c.modelMatrix[0])
+
+		gl.Uniform1f(grayscaleUniform, c.grayscale)
+		gl.Uniform1f(brightnessUniform, c.brightness)
+		gl.Uniform1f(alphaUniform, c.alpha)
+		gl.Uniform1f(mixAmountUniform, c.mixAmount)
+		gl.Uniform4fv(texCoordRectUniform, 1, &c.texCoordRect[0])
+
+		meshes[c.meshID].drawElements(c.textureID)
 	}
-	renderMenu(g, fudge)
+}
+
+// bindMaterial sets the material's uniforms and binds its texture or the fallback texture if it has none.
+func bindMaterial(m *objMaterial, fallbackTextureID string) {
+	gl.Uniform3fv(diffuseColorUniform, 1, &m.diffuse[0])
+	gl.Uniform3fv(specularColorUniform, 1, &m.specular[0])
+	gl.Uniform3fv(emissiveColorUniform, 1, &m.emissive[0])
+	gl.Uniform1f(shininessUniform, m.specularExponent)
+	gl.BindTexture(gl.TEXTURE_2D, textures[m.textureID(fallbackTextureID)])
 }
 
 func Terminate() {}
@@ -0,0 +1,370 @@
+package renderer
+
+import (
+	"image"
+	"log"
+	"math"
+
+	"github.com/btmura/blockcillin/internal/asset"
+	"github.com/btmura/blockcillin/internal/game"
+	"github.com/golang/freetype/truetype"
+)
+
+// minClipW is the smallest clip space w of a vertex that the software renderer draws.
+// Triangles with vertices closer to or behind the camera are skipped instead of clipped.
+const minClipW = 1e-3
+
+// SoftwareRenderer renders frames into images on the CPU for screenshots and thumbnails on
+// machines without a GPU. It draws the same draw list with the same meshes, textures,
+// and shading math as shader.vert and shader.frag.
+type SoftwareRenderer struct {
+	// images maps texture ID to the image drawn for that texture.
+	images map[string]*image.RGBA
+
+	// width and height are the size of the frame being rendered.
+	width, height int
+
+	// color is the frame's color buffer with 4 floats from 0 to 1 per pixel starting from the top left.
+	color []float32
+
+	// depth is the frame's depth buffer from 0 to 1 per pixel.
+	depth []float32
+}
+
+// NewSoftwareRenderer loads the meshes, textures, and fonts to render frames without GL.
+// It fills in the same meshes and fonts that Init does, so it should not be used along with Init.
+// The locale package must be initialized first so the languages' fonts can be loaded.
+func NewSoftwareRenderer() (*SoftwareRenderer, error) {
+	r := &SoftwareRenderer{images: map[string]*image.RGBA{}}
+	if err := initAssets(newMeshes, r.addImage); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// addImage adds the image to draw for the texture.
+func (r *SoftwareRenderer) addImage(id string, rgba *image.RGBA) error {
+	r.images[id] = rgba
+	return nil
+}
+
+// Render renders the game at the fudge between updates into a new image of the given size.
+// It reloads the meshes, textures, and fonts first if the current theme has changed.
+func (r *SoftwareRenderer) Render(g *game.Game, fudge float32, width, height int) *image.RGBA {
+	if asset.CurrentTheme() != loadedTheme {
+		r.reloadAssets()
+	}
+
+	r.clear(width, height)
+
+	// Build the draw list first, since laying out text can draw new glyphs into the atlases.
+	l := newDrawList(g, fudge, width, height)
+	for _, a := range atlases {
+		r.images[a.id] = a.image
+	}
+
+	perspective, ortho := newProjectionViewMatrices(width, height)
+	for _, c := range l.commands {
+		pv := perspective
+		if c.pass == drawPassOverlay {
+			pv = ortho
+		}
+		r.drawMesh(&c, meshes[c.meshID], c.modelMatrix.mult(pv))
+	}
+
+	return r.image()
+}
+
+// reloadAssets replaces the meshes, images, and fonts with the current theme's.
+// It logs the error and keeps the old ones if the theme's assets cannot be loaded.
+func (r *SoftwareRenderer) reloadAssets() {
+	oldMeshes, oldImages, oldFonts, oldAtlases := meshes, r.images, fonts, atlases
+	r.images, fonts, atlases = map[string]*image.RGBA{}, map[string]*truetype.Font{}, map[atlasKey]*glyphAtlas{}
+
+	t := asset.CurrentTheme()
+	if err := initAssets(newMeshes, r.addImage); err != nil {
+		log.Printf("theme %s: %v", t.ID, err)
+		meshes, r.images, fonts, atlases = oldMeshes, oldImages, oldFonts, oldAtlases
+		loadedTheme = t
+	}
+}
+
+// clear resets the color and depth buffers for a new frame of the given size.
+func (r *SoftwareRenderer) clear(width, height int) {
+	r.width, r.height = width, height
+	r.color = make([]float32, width*height*4)
+	r.depth = make([]float32, width*height)
+	for i := range r.depth {
+		r.depth[i] = 1
+	}
+}
+
+// image returns the color buffer as an image shown over black like the window does.
+func (r *SoftwareRenderer) image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	for i := 0; i < r.width*r.height; i++ {
+		img.Pix[i*4] = toByte(r.color[i*4])
+		img.Pix[i*4+1] = toByte(r.color[i*4+1])
+		img.Pix[i*4+2] = toByte(r.color[i*4+2])
+		img.Pix[i*4+3] = 0xff
+	}
+	return img
+}
+
+// softwareVertex is a vertex transformed by the vertex shader math.
+type softwareVertex struct {
+	// x and y are the vertex's window coordinates with y pointing down.
+	x, y float32
+
+	// z is the vertex's depth from 0 to 1.
+	z float32
+
+	// invW is 1 over the clip space w for perspective correct interpolation.
+	invW float32
+
+	// s and t are the texture coordinates.
+	s, t float32
+
+	// lighting is the light color at the vertex.
+	lighting [3]float32
+
+	// specular is the strength of the specular highlight at the vertex.
+	specular float32
+}
+
+// halfVector is the direction halfway between the directional light and the camera in view space
+// that shader.vert computes specular highlights with.
+var halfVector = vector3{directionalVector[0], directionalVector[1], directionalVector[2] + 1}.normalize()
+
+// drawMesh draws the mesh's parts with their materials and the command's texture and uniforms.
+func (r *SoftwareRenderer) drawMesh(c *drawCommand, m *mesh, mvp matrix4) {
+	mv := m.vertices
+
+	// material is the material of the part being drawn.
+	var material *objMaterial
+
+	vertex := func(index uint16) (softwareVertex, bool) {
+		i := int(index)
+		p := transformVector(mvp, mv.positions[i*3], mv.positions[i*3+1], mv.positions[i*3+2], 1)
+		if p[3] < minClipW {
+			return softwareVertex{}, false
+		}
+		invW := 1 / p[3]
+
+		// Match shader.vert which transforms the normal with a w of 1.
+		n := transformVector(normalMatrix, mv.normals[i*3], mv.normals[i*3+1], mv.normals[i*3+2], 1)
+		directional := n[0]*directionalVector[0] + n[1]*directionalVector[1] + n[2]*directionalVector[2]
+		if directional < 0 {
+			directional = 0
+		}
+
+		v := softwareVertex{
+			x:    (p[0]*invW + 1) / 2 * float32(r.width),
+			y:    (1 - p[1]*invW) / 2 * float32(r.height),
+			z:    (p[2]*invW + 1) / 2,
+			invW: invW,
+			s:    c.texCoordRect[0] + mv.texCoords[i*2]*c.texCoordRect[2],
+			t:    c.texCoordRect[1] + mv.texCoords[i*2+1]*c.texCoordRect[3],
+		}
+		for j := range v.lighting {
+			v.lighting[j] = ambientLightColor[j] + directionalLightColor[j]*directional
+		}
+		if directional > 0 {
+			facing := n[0]*halfVector.x + n[1]*halfVector.y + n[2]*halfVector.z
+			if facing < 0 {
+				facing = 0
+			}
+			v.specular = float32(math.Pow(float64(facing), math.Max(float64(material.specularExponent), 1)))
+		}
+		return v, true
+	}
+
+	for _, p := range m.parts {
+		material = p.material
+		tex := r.images[material.textureID(c.textureID)]
+		for i := p.offset; i+2 < p.offset+p.count; i += 3 {
+			v0, ok0 := vertex(m.indices[i])
+			v1, ok1 := vertex(m.indices[i+1])
+			v2, ok2 := vertex(m.indices[i+2])
+			if ok0 && ok1 && ok2 {
+				r.drawTriangle(c, material, tex, &v0, &v1, &v2)
+			}
+		}
+	}
+}
+
+// drawTriangle fills the pixels whose centers are inside the triangle, culling back faces,
+// testing and writing depth, and blending like the GL renderer is configured to.
+func (r *SoftwareRenderer) drawTriangle(c *drawCommand, material *objMaterial, tex *image.RGBA, v0, v1, v2 *softwareVertex) {
+	// Front faces are counter-clockwise with y pointing up, so clockwise with y pointing down.
+	area := edge(v0, v1, v2.x, v2.y)
+	if area >= 0 {
+		return
+	}
+
+	minX := clampInt(int(floor32(min3(v0.x, v1.x, v2.x))), 0, r.width-1)
+	maxX := clampInt(int(ceil32(max3(v0.x, v1.x, v2.x))), 0, r.width-1)
+	minY := clampInt(int(floor32(min3(v0.y, v1.y, v2.y))), 0, r.height-1)
+	maxY := clampInt(int(ceil32(max3(v0.y, v1.y, v2.y))), 0, r.height-1)
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := float32(x)+0.5, float32(y)+0.5
+
+			// The weights are all positive inside the triangle since the edges have the same sign as the area.
+			w0 := edge(v1, v2, px, py) / area
+			w1 := edge(v2, v0, px, py) / area
+			w2 := edge(v0, v1, px, py) / area
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+
+			i := y*r.width + x
+			z := w0*v0.z + w1*v1.z + w2*v2.z
+			if z >= r.depth[i] {
+				continue
+			}
+
+			// Interpolate the texture coordinates, lighting, and specular with perspective correction.
+			invW := w0*v0.invW + w1*v1.invW + w2*v2.invW
+			p0, p1, p2 := w0*v0.invW/invW, w1*v1.invW/invW, w2*v2.invW/invW
+
+			s := p0*v0.s + p1*v1.s + p2*v2.s
+			t := p0*v0.t + p1*v1.t + p2*v2.t
+			var lighting [3]float32
+			for j := range lighting {
+				lighting[j] = p0*v0.lighting[j] + p1*v1.lighting[j] + p2*v2.lighting[j]
+			}
+			specular := p0*v0.specular + p1*v1.specular + p2*v2.specular
+
+			src := shadeFragment(c, material, sampleTexture(tex, s, t), lighting, specular)
+
+			r.depth[i] = z
+			dst := r.color[i*4 : i*4+4]
+			a := src[3]
+			for j := range dst {
+				dst[j] = src[j]*a + dst[j]*(1-a)
+			}
+		}
+	}
+}
+
+// shadeFragment applies the math of shader.frag to the texture color.
+func shadeFragment(c *drawCommand, m *objMaterial, color [4]float32, lighting [3]float32, specular float32) [4]float32 {
+	for j := 0; j < 3; j++ {
+		color[j] = color[j]*m.diffuse[j] + c.brightness
+	}
+	color[3] *= c.alpha
+
+	for j := 0; j < 3; j++ {
+		color[j] = color[j]*lighting[j] + m.specular[j]*specular + m.emissive[j]
+	}
+
+	for j := 0; j < 3; j++ {
+		color[j] = mix(color[j], blackColor[j], c.mixAmount)
+	}
+
+	gray := color[0]*0.21 + color[1]*0.72 + color[2]*0.07
+	for j := 0; j < 3; j++ {
+		color[j] = mix(color[j], gray, c.grayscale)
+	}
+
+	// Clamp like the framebuffer does.
+	for j := range color {
+		color[j] = clamp32(color[j], 0, 1)
+	}
+	return color
+}
+
+// sampleTexture returns the bilinearly filtered color of the texture at s and t
+// with the texture's first row at t = 0 and edges clamped like the GL textures.
+// It returns opaque white if there is no texture.
+func sampleTexture(tex *image.RGBA, s, t float32) [4]float32 {
+	if tex == nil {
+		return [4]float32{1, 1, 1, 1}
+	}
+
+	b := tex.Bounds()
+	x := s*float32(b.Dx()) - 0.5
+	y := t*float32(b.Dy()) - 0.5
+	x0, y0 := floor32(x), floor32(y)
+	fx, fy := x-x0, y-y0
+
+	texel := func(x, y int) [4]float32 {
+		x = clampInt(x, 0, b.Dx()-1)
+		y = clampInt(y, 0, b.Dy()-1)
+		i := tex.PixOffset(b.Min.X+x, b.Min.Y+y)
+		p := tex.Pix[i : i+4]
+		return [4]float32{float32(p[0]) / 0xff, float32(p[1]) / 0xff, float32(p[2]) / 0xff, float32(p[3]) / 0xff}
+	}
+
+	c00 := texel(int(x0), int(y0))
+	c10 := texel(int(x0)+1, int(y0))
+	c01 := texel(int(x0), int(y0)+1)
+	c11 := texel(int(x0)+1, int(y0)+1)
+
+	var c [4]float32
+	for j := range c {
+		c[j] = mix(mix(c00[j], c10[j], fx), mix(c01[j], c11[j], fx), fy)
+	}
+	return c
+}
+
+// transformVector multiplies the vector by the matrix like the shaders do.
+func transformVector(m matrix4, x, y, z, w float32) [4]float32 {
+	var v [4]float32
+	for j := range v {
+		v[j] = x*m[j] + y*m[4+j] + z*m[8+j] + w*m[12+j]
+	}
+	return v
+}
+
+// edge returns twice the signed area of the triangle from a to b to the point.
+func edge(a, b *softwareVertex, x, y float32) float32 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+// mix linearly interpolates from a to b by t like GLSL's mix.
+func mix(a, b, t float32) float32 {
+	return a*(1-t) + b*t
+}
+
+func toByte(v float32) uint8 {
+	return uint8(clamp32(v, 0, 1)*0xff + 0.5)
+}
+
+func clamp32(v, min, max float32) float32 {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
+
+func clampInt(v, min, max int) int {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
+
+func floor32(v float32) float32 {
+	return float32(math.Floor(float64(v)))
+}
+
+func ceil32(v float32) float32 {
+	return float32(math.Ceil(float64(v)))
+}
+
+func min3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+func max3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
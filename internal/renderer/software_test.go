@@ -0,0 +1,296 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+func TestShadeFragment(t *testing.T) {
+	white := [4]float32{1, 1, 1, 1}
+	red := [4]float32{1, 0, 0, 1}
+	fullLight := [3]float32{1, 1, 1}
+	noLight := [3]float32{}
+
+	for _, tt := range []struct {
+		desc     string
+		command  drawCommand
+		material *objMaterial
+		color    [4]float32
+		lighting [3]float32
+		specular float32
+		want     [4]float32
+	}{
+		{
+			desc:     "lighting darkens the color",
+			command:  drawCommand{alpha: 1},
+			color:    white,
+			lighting: [3]float32{0.5, 0.25, 0},
+			want:     [4]float32{0.5, 0.25, 0, 1},
+		},
+		{
+			desc:     "brightness is clamped",
+			command:  drawCommand{alpha: 1, brightness: 0.5},
+			color:    red,
+			lighting: fullLight,
+			want:     [4]float32{1, 0.5, 0.5, 1},
+		},
+		{
+			desc:     "alpha",
+			command:  drawCommand{alpha: 0.25},
+			color:    red,
+			lighting: fullLight,
+			want:     [4]float32{1, 0, 0, 0.25},
+		},
+		{
+			desc:     "mix amount darkens toward black",
+			command:  drawCommand{alpha: 1, mixAmount: 0.75},
+			color:    white,
+			lighting: fullLight,
+			want:     [4]float32{0.25, 0.25, 0.25, 1},
+		},
+		{
+			desc:     "grayscale",
+			command:  drawCommand{alpha: 1, grayscale: 1},
+			color:    red,
+			lighting: fullLight,
+			want:     [4]float32{0.21, 0.21, 0.21, 1},
+		},
+		{
+			desc:     "diffuse color tints the texture",
+			command:  drawCommand{alpha: 1},
+			material: &objMaterial{diffuse: [3]float32{0.5, 1, 0}},
+			color:    white,
+			lighting: fullLight,
+			want:     [4]float32{0.5, 1, 0, 1},
+		},
+		{
+			desc:     "specular highlight is added to the lit color",
+			command:  drawCommand{alpha: 1},
+			material: &objMaterial{diffuse: [3]float32{1, 1, 1}, specular: [3]float32{0.5, 0.5, 0.5}},
+			color:    red,
+			lighting: [3]float32{0.5, 0.5, 0.5},
+			specular: 0.5,
+			want:     [4]float32{0.75, 0.25, 0.25, 1},
+		},
+		{
+			desc:     "emissive color glows without light",
+			command:  drawCommand{alpha: 1},
+			material: &objMaterial{diffuse: [3]float32{1, 1, 1}, emissive: [3]float32{0, 0.5, 0}},
+			color:    red,
+			lighting: noLight,
+			want:     [4]float32{0, 0.5, 0, 1},
+		},
+		{
+			desc:     "mix amount darkens the emissive color too",
+			command:  drawCommand{alpha: 1, mixAmount: 0.5},
+			material: &objMaterial{diffuse: [3]float32{1, 1, 1}, emissive: [3]float32{1, 1, 1}},
+			color:    red,
+			lighting: noLight,
+			want:     [4]float32{0.5, 0.5, 0.5, 1},
+		},
+	} {
+		m := tt.material
+		if m == nil {
+			m = defaultMaterial
+		}
+		if got := shadeFragment(&tt.command, m, tt.color, tt.lighting, tt.specular); got != tt.want {
+			t.Errorf("[%s] shadeFragment(%v, %v, %v, %v, %v) = %v, want %v", tt.desc, tt.command, m, tt.color, tt.lighting, tt.specular, got, tt.want)
+		}
+	}
+}
+
+func TestSoftwareRendererDrawMesh(t *testing.T) {
+	solid := func(c color.RGBA) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		img.Set(0, 0, c)
+		return img
+	}
+
+	// square returns a mesh that covers the whole frame at the given depth.
+	square := func(z float32, clockwise bool) *mesh {
+		m := &mesh{
+			vertices: &meshVertices{
+				positions: []float32{-1, -1, z, 1, -1, z, 1, 1, z, -1, 1, z},
+				normals:   make([]float32, 12),
+				texCoords: make([]float32, 8),
+			},
+			indices: []uint16{0, 1, 2, 0, 2, 3},
+			parts:   []*meshPart{{material: defaultMaterial, count: 6}},
+		}
+		if clockwise {
+			m.indices = []uint16{0, 2, 1, 0, 3, 2}
+		}
+		return m
+	}
+
+	type draw struct {
+		command drawCommand
+		mesh    *mesh
+	}
+
+	red := drawCommand{textureID: "red", texCoordRect: fullTexCoordRect, alpha: 1}
+	blue := drawCommand{textureID: "blue", texCoordRect: fullTexCoordRect, alpha: 1}
+	halfBlue := drawCommand{textureID: "blue", texCoordRect: fullTexCoordRect, alpha: 0.5}
+
+	pixel := func(draws ...draw) color.RGBA {
+		r := &SoftwareRenderer{images: map[string]*image.RGBA{
+			"red":  solid(color.RGBA{0xff, 0, 0, 0xff}),
+			"blue": solid(color.RGBA{0, 0, 0xff, 0xff}),
+		}}
+		r.clear(4, 4)
+		for _, d := range draws {
+			r.drawMesh(&d.command, d.mesh, newScaleMatrix(1, 1, 1))
+		}
+
+		// Every pixel should be the same since the meshes cover the whole frame.
+		img := r.image()
+		want := img.RGBAAt(0, 0)
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				if got := img.RGBAAt(x, y); got != want {
+					t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+				}
+			}
+		}
+		return want
+	}
+
+	redPixel := pixel(draw{red, square(0, false)})
+	bluePixel := pixel(draw{blue, square(0, false)})
+
+	blueMaterialSquare := square(0, false)
+	blueMaterialSquare.parts[0].material = &objMaterial{diffuse: [3]float32{1, 1, 1}, diffuseMap: "blue"}
+
+	for _, tt := range []struct {
+		desc  string
+		draws []draw
+		want  color.RGBA
+	}{
+		{
+			desc:  "back faces are culled",
+			draws: []draw{{red, square(0, true)}},
+			want:  color.RGBA{0, 0, 0, 0xff},
+		},
+		{
+			desc:  "farther mesh is hidden behind nearer mesh",
+			draws: []draw{{red, square(-0.5, false)}, {blue, square(0, false)}},
+			want:  redPixel,
+		},
+		{
+			desc:  "nearer mesh covers farther mesh",
+			draws: []draw{{red, square(0, false)}, {blue, square(-0.5, false)}},
+			want:  bluePixel,
+		},
+		{
+			desc:  "transparent mesh blends with the mesh behind it",
+			draws: []draw{{red, square(0, false)}, {halfBlue, square(-0.5, false)}},
+			want:  color.RGBA{(redPixel.R + 1) / 2, 0, (bluePixel.B + 1) / 2, 0xff},
+		},
+		{
+			desc:  "material's texture is drawn instead of the command's texture",
+			draws: []draw{{red, blueMaterialSquare}},
+			want:  bluePixel,
+		},
+	} {
+		if got := pixel(tt.draws...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] pixel = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestSoftwareRendererRender(t *testing.T) {
+	// maxChannelDiff is how much a pixel's channels can differ from the golden image,
+	// since floating point math can round differently on other machines.
+	const maxChannelDiff = 2
+
+	for _, tt := range []struct {
+		desc   string
+		golden string
+		game   func() *game.Game
+	}{
+		{
+			desc:   "playing board with every kind of block and a marker",
+			golden: "playing",
+			game: func() *game.Game {
+				return newTestGame(game.GamePlaying, newTestBoard(game.BoardLive))
+			},
+		},
+		{
+			desc:   "paused board is gray and dark under the menu",
+			golden: "paused",
+			game: func() *game.Game {
+				return newTestGame(game.GamePaused, newTestBoard(game.BoardLive))
+			},
+		},
+	} {
+		initTestText(t)
+		r, err := NewSoftwareRenderer()
+		if err != nil {
+			t.Fatalf("NewSoftwareRenderer: %v", err)
+		}
+		got := r.Render(tt.game(), 0.5, 400, 300)
+
+		path := filepath.Join("testdata", "software_"+tt.golden+".png")
+		if *update {
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatalf("[%s] Create: %v", tt.desc, err)
+			}
+			if err := png.Encode(f, got); err != nil {
+				t.Fatalf("[%s] png.Encode: %v", tt.desc, err)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatalf("[%s] Close: %v", tt.desc, err)
+			}
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("[%s] Open: %v", tt.desc, err)
+		}
+		want, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("[%s] png.Decode: %v", tt.desc, err)
+		}
+
+		if got.Bounds() != want.Bounds() {
+			t.Errorf("[%s] bounds = %v, want %v from %s", tt.desc, got.Bounds(), want.Bounds(), path)
+			continue
+		}
+
+		diffs := 0
+		b := got.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				g := got.RGBAAt(x, y)
+				w := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+				if channelDiff(g.R, w.R) > maxChannelDiff || channelDiff(g.G, w.G) > maxChannelDiff || channelDiff(g.B, w.B) > maxChannelDiff {
+					if diffs == 0 {
+						t.Errorf("[%s] pixel (%d, %d) = %v, want %v from %s", tt.desc, x, y, g, w, path)
+					}
+					diffs++
+				}
+			}
+		}
+		if diffs > 0 {
+			t.Errorf("[%s] %d pixels differ from %s", tt.desc, diffs, path)
+		}
+	}
+}
+
+// channelDiff returns the absolute difference between two color channels.
+func channelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
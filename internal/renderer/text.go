@@ -1,94 +1,270 @@
 package renderer
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
+	"log"
 
-	"github.com/go-gl/gl/v3.3-core/gl"
-	"github.com/golang/freetype"
+	"github.com/btmura/blockcillin/internal/locale"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
-type renderableText struct {
-	text  string
-	size  int
+const (
+	// atlasSize is the width and height of each glyph atlas's image.
+	atlasSize = 1024
+
+	// glyphPadding is the transparent space around each glyph in an atlas
+	// so that filtering does not bleed neighboring glyphs into each other.
+	glyphPadding = 1
+)
+
+// fullTexCoordRect is the texture coordinate rectangle that maps a mesh to its whole texture.
+var fullTexCoordRect = [4]float32{0, 0, 1, 1}
+
+var (
+	// fonts maps font asset name to the fonts of the languages' catalogs.
+	fonts = map[string]*truetype.Font{}
+
+	// atlases maps atlas key to the glyph atlas that draws text with that font, size, and color.
+	// Atlases are created the first time text is drawn with them.
+	atlases = map[atlasKey]*glyphAtlas{}
+)
+
+// textStyle is the weight, size, and color that text is drawn in.
+// The font is the current language's font so that it covers the language's script.
+type textStyle struct {
+	// bold is whether to draw with the language's bold font instead of its plain font.
+	bold bool
+
+	// size is the font size in pixels.
+	size int
+
+	// color is the color of the text.
 	color color.Color
+}
+
+// atlasKey is the font, size, and color that identifies a glyph atlas.
+type atlasKey struct {
+	// font is the name of the font asset.
+	font string
 
-	texture uint32
-	width   float32
-	height  float32
+	// size is the font size in pixels.
+	size int
+
+	// color is the color of the glyphs.
+	color color.Color
 }
 
-func createText(text string, size int, color color.Color, f *truetype.Font, textureUnit uint32) (*renderableText, error) {
-	rgba, width, height, err := createTextImage(text, size, color, f)
-	if err != nil {
-		return nil, err
-	}
+// textureID returns the texture ID of the key's glyph atlas.
+func (k atlasKey) textureID() string {
+	r, g, b, a := k.color.RGBA()
+	return fmt.Sprintf("text:%s:%d:%04x%04x%04x%04x", k.font, k.size, r, g, b, a)
+}
 
-	texture, err := createTexture(textureUnit, rgba)
-	if err != nil {
-		return nil, err
-	}
+// glyphAtlas is a texture with the glyphs of a font drawn at one size and color.
+// Glyphs are drawn into it the first time they are laid out.
+type glyphAtlas struct {
+	// id is the texture ID of the atlas.
+	id string
+
+	// face is the font face that glyphs are drawn from.
+	face font.Face
+
+	// src is the color to draw glyphs with.
+	src image.Image
+
+	// image is the atlas's image with the glyphs drawn so far.
+	image *image.RGBA
+
+	// glyphs maps rune to glyph or nil if the rune has no glyph.
+	glyphs map[rune]*glyph
+
+	// shelf is where the next glyph is drawn on the current row of glyphs.
+	shelf image.Point
+
+	// shelfHeight is the height of the tallest glyph on the current row of glyphs.
+	shelfHeight int
+
+	// ascent and descent are the distances from the baseline to the top and bottom of a line.
+	ascent, descent int
+
+	// dirty is whether glyphs have been drawn since the image was last uploaded.
+	dirty bool
+}
+
+// glyph is the metrics and atlas location of a glyph.
+type glyph struct {
+	// bounds is the glyph's bounds relative to the pen on the baseline with y pointing down.
+	bounds image.Rectangle
+
+	// rect is where the glyph is in the atlas's image. It is empty for glyphs like spaces.
+	rect image.Rectangle
+
+	// advance is how far the pen moves after the glyph.
+	advance fixed.Int26_6
+}
+
+// textLayout is a line of text laid out in a text style.
+type textLayout struct {
+	// glyphs are the glyphs with pixels to draw in the order of the text.
+	glyphs []placedGlyph
 
-	return &renderableText{
-		text:  text,
-		size:  size,
-		color: color,
+	// width is the width of the line from the first glyph's pen to the last glyph's advance.
+	width float32
 
-		texture: texture,
-		width:   width,
-		height:  height,
-	}, nil
+	// height is the height of the line, which is the same for any text in the style.
+	height float32
 }
 
-func (rt *renderableText) render(x, y float32) {
-	m := newScaleMatrix(rt.width, rt.height, 1)
-	m = m.mult(newTranslationMatrix(x, y, 0))
-	gl.UniformMatrix4fv(modelMatrixUniform, 1, false, &m[0])
-	gl.Uniform1i(textureUniform, int32(rt.texture)-1)
-	textLineMesh.drawElements()
+// placedGlyph is a glyph placed on a line of text.
+type placedGlyph struct {
+	// x and y are the offset of the glyph's lower left corner from the line's lower left corner.
+	x, y float32
+
+	// width and height are the glyph's size in pixels.
+	width, height float32
+
+	// texCoordRect is the glyph's rectangle in the atlas as texture coordinates
+	// of the top left corner followed by the width and height.
+	texCoordRect [4]float32
+}
+
+// atlasFor returns the glyph atlas for the style in the current language,
+// creating it the first time it is used.
+func atlasFor(s textStyle) *glyphAtlas {
+	fontKey := locale.PlainFontKey
+	if s.bold {
+		fontKey = locale.BoldFontKey
+	}
+
+	k := atlasKey{font: locale.Text(fontKey), size: s.size, color: s.color}
+	if a, ok := atlases[k]; ok {
+		return a
+	}
+
+	f, ok := fonts[k.font]
+	if !ok {
+		panic(fmt.Sprintf("font not loaded: %s", k.font))
+	}
+	a := newGlyphAtlas(k.textureID(), f, k.size, k.color)
+	atlases[k] = a
+	return a
 }
 
-func createTextImage(text string, fontSize int, color color.Color, f *truetype.Font) (*image.RGBA, float32, float32, error) {
-	// 1 pt = 1/72 in, 72 dpi = 1 in
-	const dpi = 72
+// newGlyphAtlas returns an empty atlas for the font at the size in pixels and color.
+func newGlyphAtlas(id string, f *truetype.Font, size int, color color.Color) *glyphAtlas {
+	face := truetype.NewFace(f, &truetype.Options{
+		Size:    float64(size),
+		DPI:     72, // 1 pt = 1/72 in, so points are pixels.
+		Hinting: font.HintingFull,
+	})
+	m := face.Metrics()
+	return &glyphAtlas{
+		id:      id,
+		face:    face,
+		src:     image.NewUniform(color),
+		image:   image.NewRGBA(image.Rect(0, 0, atlasSize, atlasSize)),
+		glyphs:  map[rune]*glyph{},
+		ascent:  m.Ascent.Ceil(),
+		descent: m.Descent.Ceil(),
+	}
+}
 
-	fg, bg := image.NewUniform(color), image.Transparent
+// layout lays out the text on one line, drawing any glyphs it has not drawn yet.
+func (a *glyphAtlas) layout(text string) *textLayout {
+	l := &textLayout{height: float32(a.ascent + a.descent)}
 
-	c := freetype.NewContext()
-	c.SetFont(f)
-	c.SetDPI(dpi)
-	c.SetFontSize(float64(fontSize)) // points
-	c.SetSrc(fg)
-	c.SetHinting(font.HintingFull)
+	var pen fixed.Int26_6
+	prev := rune(-1)
+	for _, r := range text {
+		if prev >= 0 {
+			pen += a.face.Kern(prev, r)
+		}
+		prev = r
 
-	// 1. Figure out maximum height so all text lines are the same height.
-	// 2. Draw within small bounds to figure out bounds.
-	// 3. Draw within final bounds.
+		g := a.glyph(r)
+		if g == nil {
+			continue
+		}
 
-	scale := c.PointToFixed(float64(fontSize)) // point to pixels
-	rect := f.Bounds(scale)                    // scale is pixels of 1 em
-	maxHeight := int(rect.Max.Y>>6) - int(rect.Min.Y>>6)
+		if !g.rect.Empty() {
+			l.glyphs = append(l.glyphs, placedGlyph{
+				x:      float32(pen.Round() + g.bounds.Min.X),
+				y:      float32(a.descent - g.bounds.Max.Y),
+				width:  float32(g.bounds.Dx()),
+				height: float32(g.bounds.Dy()),
+				texCoordRect: [4]float32{
+					float32(g.rect.Min.X) / atlasSize,
+					float32(g.rect.Min.Y) / atlasSize,
+					float32(g.rect.Dx()) / atlasSize,
+					float32(g.rect.Dy()) / atlasSize,
+				},
+			})
+		}
+		pen += g.advance
+	}
+	l.width = float32(pen.Round())
+	return l
+}
 
-	var rgba *image.RGBA
-	w, h := 10, maxHeight
-	for i := 0; i < 2; i++ {
-		rgba = image.NewRGBA(image.Rect(0, 0, w, h))
-		draw.Draw(rgba, rgba.Bounds(), bg, image.ZP, draw.Src)
+// glyph returns the rune's glyph, drawing it into the atlas the first time it is needed.
+// It returns nil if the font has no glyph for the rune.
+func (a *glyphAtlas) glyph(r rune) *glyph {
+	if g, ok := a.glyphs[r]; ok {
+		return g
+	}
 
-		c.SetClip(rgba.Bounds())
-		c.SetDst(rgba)
+	dr, mask, maskp, advance, ok := a.face.Glyph(fixed.Point26_6{}, r)
+	if !ok {
+		a.glyphs[r] = nil
+		return nil
+	}
 
-		pt := freetype.Pt(0, int(scale>>6))
-		end, err := c.DrawString(text, pt)
-		if err != nil {
-			return nil, 0, 0, err
+	g := &glyph{bounds: dr, advance: advance}
+	if !dr.Empty() {
+		if rect, ok := a.allocate(dr.Dx(), dr.Dy()); ok {
+			draw.DrawMask(a.image, rect, a.src, image.ZP, mask, maskp, draw.Over)
+			g.rect = rect
+			a.dirty = true
+		} else {
+			log.Printf("glyph atlas full: %s: %q", a.id, r)
 		}
+	}
+	a.glyphs[r] = g
+	return g
+}
+
+// allocate returns where to draw a glyph of the given size in the atlas's image
+// or false if there is no room left.
+func (a *glyphAtlas) allocate(width, height int) (image.Rectangle, bool) {
+	w, h := width+glyphPadding*2, height+glyphPadding*2
+	if w > atlasSize {
+		return image.Rectangle{}, false
+	}
 
-		w = int(end.X >> 6)
+	// Start a new row if the glyph does not fit on the current one.
+	if a.shelf.X+w > atlasSize {
+		a.shelf = image.Pt(0, a.shelf.Y+a.shelfHeight)
+		a.shelfHeight = 0
+	}
+	if a.shelf.Y+h > atlasSize {
+		return image.Rectangle{}, false
 	}
 
-	return rgba, float32(w), float32(h), nil
+	min := a.shelf.Add(image.Pt(glyphPadding, glyphPadding))
+	a.shelf.X += w
+	if h > a.shelfHeight {
+		a.shelfHeight = h
+	}
+	return image.Rectangle{min, min.Add(image.Pt(width, height))}, true
+}
+
+// measureText returns the size of the text laid out in the style.
+func measureText(s textStyle, text string) (width, height float32) {
+	l := atlasFor(s).layout(text)
+	return l.width, l.height
 }
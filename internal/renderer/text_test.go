@@ -0,0 +1,142 @@
+package renderer
+
+import (
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/math/fixed"
+)
+
+func TestGlyphAtlasLayout(t *testing.T) {
+	initTestText(t)
+	style := textStyle{size: 36, color: color.White}
+
+	for _, tt := range []struct {
+		desc       string
+		text       string
+		wantGlyphs int
+	}{
+		{
+			desc: "empty text",
+		},
+		{
+			desc:       "spaces have an advance but no glyph to draw",
+			text:       "A B",
+			wantGlyphs: 2,
+		},
+		{
+			desc:       "non-ASCII text",
+			text:       "Ünïcödé",
+			wantGlyphs: 7,
+		},
+	} {
+		a := atlasFor(style)
+		l := a.layout(tt.text)
+
+		if len(l.glyphs) != tt.wantGlyphs {
+			t.Errorf("[%s] layout(%q) has %d glyphs, want %d", tt.desc, tt.text, len(l.glyphs), tt.wantGlyphs)
+		}
+
+		// The width is the sum of the advances and kerning between each pair of runes.
+		var wantWidth fixed.Int26_6
+		prev := rune(-1)
+		for _, r := range tt.text {
+			if prev >= 0 {
+				wantWidth += a.face.Kern(prev, r)
+			}
+			prev = r
+			wantWidth += a.glyphs[r].advance
+		}
+		if l.width != float32(wantWidth.Round()) {
+			t.Errorf("[%s] layout(%q) width = %v, want %v", tt.desc, tt.text, l.width, wantWidth.Round())
+		}
+
+		if want := float32(a.ascent + a.descent); l.height != want {
+			t.Errorf("[%s] layout(%q) height = %v, want %v", tt.desc, tt.text, l.height, want)
+		}
+
+		// Glyphs should be laid out from left to right with texture coordinates inside the atlas.
+		for i, g := range l.glyphs {
+			if i > 0 && g.x <= l.glyphs[i-1].x {
+				t.Errorf("[%s] layout(%q) glyph %d x = %v, want more than %v", tt.desc, tt.text, i, g.x, l.glyphs[i-1].x)
+			}
+			r := g.texCoordRect
+			if r[0] < 0 || r[1] < 0 || r[0]+r[2] > 1 || r[1]+r[3] > 1 {
+				t.Errorf("[%s] layout(%q) glyph %d texCoordRect = %v, want within the atlas", tt.desc, tt.text, i, r)
+			}
+		}
+	}
+}
+
+func TestGlyphAtlasReusesGlyphs(t *testing.T) {
+	initTestText(t)
+	a := atlasFor(textStyle{bold: true, size: 20, color: color.White})
+
+	first := a.layout("AB")
+	a.dirty = false
+
+	second := a.layout("BA")
+	if a.dirty {
+		t.Errorf("layout(%q) drew glyphs again after layout(%q)", "BA", "AB")
+	}
+	if first.glyphs[0].texCoordRect != second.glyphs[1].texCoordRect || first.glyphs[1].texCoordRect != second.glyphs[0].texCoordRect {
+		t.Errorf("layout(%q) texture coordinates differ from layout(%q)", "BA", "AB")
+	}
+	if first.glyphs[0].texCoordRect == first.glyphs[1].texCoordRect {
+		t.Errorf("layout(%q) drew both glyphs in the same place", "AB")
+	}
+}
+
+func TestGlyphAtlasAllocate(t *testing.T) {
+	a := &glyphAtlas{}
+	for _, tt := range []struct {
+		desc          string
+		width, height int
+		wantX, wantY  int
+		wantOK        bool
+	}{
+		{
+			desc:   "first glyph is padded from the corner",
+			width:  10,
+			height: 20,
+			wantX:  glyphPadding,
+			wantY:  glyphPadding,
+			wantOK: true,
+		},
+		{
+			desc:   "next glyph is on the same row",
+			width:  10,
+			height: 10,
+			wantX:  10 + glyphPadding*3,
+			wantY:  glyphPadding,
+			wantOK: true,
+		},
+		{
+			desc:   "glyph that does not fit on the row starts a new row under the tallest glyph",
+			width:  atlasSize - 10,
+			height: 10,
+			wantX:  glyphPadding,
+			wantY:  20 + glyphPadding*3,
+			wantOK: true,
+		},
+		{
+			desc:   "glyph wider than the atlas",
+			width:  atlasSize,
+			height: 10,
+		},
+		{
+			desc:   "glyph taller than the space left",
+			width:  10,
+			height: atlasSize,
+		},
+	} {
+		r, ok := a.allocate(tt.width, tt.height)
+		if ok != tt.wantOK {
+			t.Errorf("[%s] allocate(%d, %d) ok = %t, want %t", tt.desc, tt.width, tt.height, ok, tt.wantOK)
+			continue
+		}
+		if ok && (r.Min.X != tt.wantX || r.Min.Y != tt.wantY || r.Dx() != tt.width || r.Dy() != tt.height) {
+			t.Errorf("[%s] allocate(%d, %d) = %v, want %d by %d at (%d, %d)", tt.desc, tt.width, tt.height, r, tt.width, tt.height, tt.wantX, tt.wantY)
+		}
+	}
+}
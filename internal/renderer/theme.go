@@ -0,0 +1,98 @@
+package renderer
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/btmura/blockcillin/internal/asset"
+	"github.com/btmura/blockcillin/internal/locale"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+)
+
+// meshesAssetName is the name of the OBJ asset with the meshes.
+const meshesAssetName = "meshes.obj"
+
+// loadedTheme is the theme that the meshes, textures, and fonts were loaded from.
+// Renderers reload them when the current theme changes.
+var loadedTheme *asset.Theme
+
+// ValidateTheme checks that the theme's meshes, textures, and fonts can be loaded and
+// that its meshes have every mesh ID that the renderer draws. Pass it to asset.InitThemes
+// to skip broken themes. The locale package must be initialized first.
+func ValidateTheme(t *asset.Theme) error {
+	r, err := t.Reader(meshesAssetName)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	objs, err := decodeMeshObjs(r, func(name string) (io.ReadCloser, error) {
+		return t.Reader(name)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", meshesAssetName, err)
+	}
+
+	for _, id := range textureIDs(objs) {
+		r, err := t.Reader(id)
+		if err != nil {
+			return err
+		}
+		_, err = decodeImage(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", id, err)
+		}
+	}
+
+	for _, l := range locale.Languages() {
+		for _, key := range []string{locale.PlainFontKey, locale.BoldFontKey} {
+			name := l.Text(key)
+			b, err := t.Asset(name)
+			if err != nil {
+				return err
+			}
+			if _, err := freetype.ParseFont(b); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reloadGLAssets replaces the GL meshes and textures and the fonts with the current theme's.
+// It keeps the old ones and sets assetsErr if the theme's assets cannot be loaded.
+func reloadGLAssets() {
+	oldMeshes, oldTextures, oldFonts, oldAtlases := meshes, textures, fonts, atlases
+	meshes, textures, fonts, atlases = nil, map[string]uint32{}, map[string]*truetype.Font{}, map[atlasKey]*glyphAtlas{}
+
+	t := asset.CurrentTheme()
+	if assetsErr = initAssets(createMeshes, addGLTexture); assetsErr != nil {
+		log.Printf("theme %s: %v", t.ID, assetsErr)
+
+		// Delete whatever was created before the error and go back to the old assets.
+		if meshes != nil {
+			deleteMeshes(meshes)
+		}
+		deleteTextures(textures)
+		meshes, textures, fonts, atlases = oldMeshes, oldTextures, oldFonts, oldAtlases
+
+		// Do not try again every frame.
+		loadedTheme = t
+		return
+	}
+
+	deleteMeshes(oldMeshes)
+	deleteTextures(oldTextures)
+	log.Printf("theme %s: loaded", t.ID)
+}
+
+// deleteTextures deletes the GL textures.
+func deleteTextures(ts map[string]uint32) {
+	for _, t := range ts {
+		gl.DeleteTextures(1, &t)
+	}
+}
@@ -0,0 +1,115 @@
+package renderer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/asset"
+	"github.com/btmura/blockcillin/internal/locale"
+)
+
+func TestValidateTheme(t *testing.T) {
+	if err := locale.Init(locale.English); err != nil {
+		t.Fatalf("locale.Init: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "themes")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	meshes, err := asset.Asset(meshesAssetName)
+	if err != nil {
+		t.Fatalf("asset.Asset: %v", err)
+	}
+
+	materials, err := asset.String("meshes.mtl")
+	if err != nil {
+		t.Fatalf("asset.String: %v", err)
+	}
+
+	for _, tt := range []struct {
+		id    string
+		files map[string]string
+	}{
+		{
+			id: "copy",
+			files: map[string]string{
+				"theme.txt": "meshes.obj = copy.obj\n",
+				"copy.obj":  string(meshes),
+			},
+		},
+		{
+			id: "tinted",
+			files: map[string]string{
+				"theme.txt":  "meshes.mtl = tinted.mtl\n",
+				"tinted.mtl": strings.Replace(materials, "Kd 1 1 1", "Kd 1 0.5 0.5", -1),
+			},
+		},
+		{
+			id: "missing_material_texture",
+			files: map[string]string{
+				"theme.txt":   "meshes.mtl = missing.mtl\n",
+				"missing.mtl": strings.Replace(materials, "map_Kd texture.png", "map_Kd missing.png", 1),
+			},
+		},
+		{
+			id: "missing_selector",
+			files: map[string]string{
+				"theme.txt": "meshes.obj = selector.obj\n",
+				"selector.obj": `
+					o square
+					v 0 0 0
+					v 1 0 0
+					v 1 1 0
+					vt 0 0
+					vn 0 0 1
+					f 1/1/1 2/1/1 3/1/1
+				`,
+			},
+		},
+		{
+			id: "bad_texture",
+			files: map[string]string{
+				"theme.txt":   "texture.png = texture.png\n",
+				"texture.png": "not a png",
+			},
+		},
+		{
+			id: "bad_font",
+			files: map[string]string{
+				"theme.txt": "CPMono_v07 Bold.ttf = bold.ttf\n",
+				"bold.ttf":  "not a font",
+			},
+		},
+	} {
+		for name, content := range tt.files {
+			p := filepath.Join(dir, tt.id, name)
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				t.Fatalf("os.MkdirAll: %v", err)
+			}
+			if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+				t.Fatalf("ioutil.WriteFile: %v", err)
+			}
+		}
+	}
+
+	defer asset.InitThemes("", nil)
+	if err := asset.InitThemes(dir, ValidateTheme); err != nil {
+		t.Fatalf("asset.InitThemes: %v", err)
+	}
+
+	// Only the themes that can be loaded and have every mesh should be left.
+	var ids []string
+	for _, th := range asset.Themes() {
+		ids = append(ids, th.ID)
+	}
+	if want := []string{asset.DefaultTheme, "copy", "tinted"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("asset.Themes() IDs = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,55 @@
+package term
+
+import "github.com/btmura/blockcillin/internal/game"
+
+const (
+	ctrlC = 0x03
+	esc   = 0x1b
+)
+
+// arrowKeys maps the final bytes of the arrow keys' escape sequences to game keys.
+var arrowKeys = map[byte]game.Key{
+	'A': game.KeyUp,
+	'B': game.KeyDown,
+	'C': game.KeyRight,
+	'D': game.KeyLeft,
+}
+
+// decodeKeys returns the game keys in the bytes read from a terminal in raw mode
+// and whether Ctrl+C was pressed to quit. Unknown keys and escape sequences are skipped.
+func decodeKeys(buf []byte) (keys []game.Key, quit bool) {
+	for i := 0; i < len(buf); i++ {
+		switch buf[i] {
+		case ctrlC:
+			return keys, true
+
+		case '\r', '\n':
+			keys = append(keys, game.KeyEnter)
+
+		case ' ':
+			keys = append(keys, game.KeySpace)
+
+		case 'r', 'R':
+			keys = append(keys, game.KeyRaise)
+
+		case esc:
+			// Arrow keys are sent as ESC [ or ESC O in application mode followed by a letter.
+			if i+2 < len(buf) && (buf[i+1] == '[' || buf[i+1] == 'O') {
+				if k, ok := arrowKeys[buf[i+2]]; ok {
+					keys = append(keys, k)
+					i += 2
+					continue
+				}
+
+				// Skip the parameters up to the final byte of other sequences.
+				i += 2
+				for i < len(buf) && (buf[i] < 0x40 || buf[i] > 0x7e) {
+					i++
+				}
+				continue
+			}
+			keys = append(keys, game.KeyEscape)
+		}
+	}
+	return keys, false
+}
@@ -0,0 +1,54 @@
+package term
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	for _, tt := range []struct {
+		desc     string
+		input    string
+		wantKeys []game.Key
+		wantQuit bool
+	}{
+		{
+			desc:     "arrow keys",
+			input:    "\x1b[A\x1b[B\x1b[C\x1b[D",
+			wantKeys: []game.Key{game.KeyUp, game.KeyDown, game.KeyRight, game.KeyLeft},
+		},
+		{
+			desc:     "arrow keys in application mode",
+			input:    "\x1bOA\x1bOD",
+			wantKeys: []game.Key{game.KeyUp, game.KeyLeft},
+		},
+		{
+			desc:     "swap, select, and raise keys",
+			input:    " \rr",
+			wantKeys: []game.Key{game.KeySpace, game.KeyEnter, game.KeyRaise},
+		},
+		{
+			desc:     "lone escape",
+			input:    "\x1b",
+			wantKeys: []game.Key{game.KeyEscape},
+		},
+		{
+			desc:     "unknown sequences and keys are skipped",
+			input:    "\x1b[15~a\x1b[1;5C ",
+			wantKeys: []game.Key{game.KeySpace},
+		},
+		{
+			desc:     "ctrl+c quits",
+			input:    "\x1b[A\x03 ",
+			wantKeys: []game.Key{game.KeyUp},
+			wantQuit: true,
+		},
+	} {
+		gotKeys, gotQuit := decodeKeys([]byte(tt.input))
+		if !reflect.DeepEqual(gotKeys, tt.wantKeys) || gotQuit != tt.wantQuit {
+			t.Errorf("[%s] decodeKeys(%q) = (%v, %t), want (%v, %t)", tt.desc, tt.input, gotKeys, gotQuit, tt.wantKeys, tt.wantQuit)
+		}
+	}
+}
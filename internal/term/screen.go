@@ -0,0 +1,224 @@
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+// ANSI escape sequences used to draw the screen.
+const (
+	cursorHome  = "\x1b[H"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+	clearScreen = "\x1b[2J"
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+	resetStyle  = "\x1b[0m"
+)
+
+// visibleCellRadius is how many cells are shown on each side of the selector.
+// The cylinder's back face is hidden like it is in the GL renderer.
+const visibleCellRadius = 4
+
+// blockColorCodes maps block colors to ANSI color numbers.
+var blockColorCodes = map[game.BlockColor]int{
+	game.Red:    1,
+	game.Purple: 5,
+	game.Blue:   4,
+	game.Cyan:   6,
+	game.Green:  2,
+	game.Yellow: 3,
+}
+
+// blockKindGlyphs maps block kinds to the two characters drawn on the block.
+var blockKindGlyphs = [...]string{
+	game.BlockNormal:  "  ",
+	game.BlockRainbow: "<>",
+	game.BlockBomb:    "()",
+	game.BlockLocked:  "##",
+}
+
+// helpText lists the keys under the board.
+const helpText = "arrows move  space swap  r raise  esc pause  ctrl+c quit"
+
+// render returns the text and escape sequences that draw the game over the previous frame.
+func render(g *game.Game) string {
+	var lines []string
+	switch g.State {
+	case game.GameInitial, game.GamePaused:
+		lines = menuLines(g.Menu)
+
+	case game.GamePlaying:
+		lines = append(lines, hudLine(g.HUD), "")
+		lines = append(lines, boardLines(g.Board, g.GlobalPulse)...)
+		lines = append(lines, "", helpText)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(cursorHome)
+	for _, l := range lines {
+		// Raw mode turns off output processing, so return the carriage explicitly.
+		buf.WriteString(l + resetStyle + clearLine + "\r\n")
+	}
+	buf.WriteString(clearBelow)
+	return buf.String()
+}
+
+// hudLine returns the line with the speed, time, and score.
+func hudLine(h *game.HUD) string {
+	return fmt.Sprintf("%s %d   %s %s   %s %d",
+		game.HUDItemSpeed.Text(), h.Speed,
+		game.HUDItemTime.Text(), formattedTime(h.TimeSec),
+		game.HUDItemScore.Text(), h.Score)
+}
+
+func formattedTime(sec int) string {
+	h := sec / 3600
+	m := sec / 60 % 60
+	s := sec % 60
+	if h != 0 {
+		return fmt.Sprintf("%d:%0.2d:%0.2d", h, m, s)
+	}
+	return fmt.Sprintf("%0.2d:%0.2d", m, s)
+}
+
+// boardLines returns a line for each ring followed by the dimmed spare rings.
+func boardLines(b *game.Board, pulse float32) []string {
+	var lines []string
+	for y, r := range b.Rings {
+		lines = append(lines, ringLine(b, r, y == b.Selector.Y, false, pulse))
+	}
+	for _, r := range b.SpareRings {
+		lines = append(lines, ringLine(b, r, false, true, pulse))
+	}
+	return lines
+}
+
+// ringLine returns the cells of the ring around the selector with brackets
+// around the selected pair of cells if the selector is on the ring.
+func ringLine(b *game.Board, r *game.Ring, selected, spare bool, pulse float32) string {
+	var buf bytes.Buffer
+	for i := 1 - visibleCellRadius; i <= visibleCellRadius+1; i++ {
+		switch {
+		case selected && i == 0:
+			buf.WriteString("[")
+		case selected && i == 2:
+			buf.WriteString("]")
+		default:
+			buf.WriteString(" ")
+		}
+
+		if i <= visibleCellRadius {
+			x := (b.Selector.X + i) % b.CellCount
+			if x < 0 {
+				x += b.CellCount
+			}
+			buf.WriteString(cellText(r.Cells[x], spare, pulse))
+		}
+	}
+	return buf.String()
+}
+
+// cellText returns the two characters that draw the cell's block or marker.
+func cellText(c *game.Cell, spare bool, pulse float32) string {
+	if text := markerText(c.Marker); text != "" {
+		if len(text) > 2 {
+			text = text[len(text)-2:]
+		}
+		return style(1, 37, 40) + fmt.Sprintf("%-2s", text) + resetStyle
+	}
+
+	b := c.Block
+	bg, fg := 40+blockColorCodes[b.Color], 30
+	switch b.Kind {
+	case game.BlockRainbow:
+		bg = 47
+	case game.BlockLocked:
+		bg, fg = 100, 37
+	}
+	glyph := blockKindGlyphs[b.Kind]
+
+	if spare {
+		return style(bg-10) + "▒▒" + resetStyle
+	}
+
+	switch b.State {
+	case game.BlockStatic, game.BlockSwappingFromLeft, game.BlockSwappingFromRight, game.BlockDroppingFromAbove:
+		return style(bg, fg) + glyph + resetStyle
+
+	case game.BlockFlashing:
+		// Alternate with the bright colors to flash.
+		if int(pulse/4)%2 == 0 {
+			bg += 60
+		}
+		return style(bg, fg) + glyph + resetStyle
+
+	case game.BlockCracking, game.BlockCracked:
+		return style(bg-10) + "▓▓" + resetStyle
+
+	case game.BlockExploding:
+		return style(bg-10) + "░░" + resetStyle
+	}
+	return "  "
+}
+
+// markerText returns the chain or combo shown by the marker or empty if there is none.
+func markerText(m *game.Marker) string {
+	if m.State != game.MarkerShowing {
+		return ""
+	}
+	switch {
+	case m.ChainLevel > 0:
+		return "x" + strconv.Itoa(m.ChainLevel+1)
+	case m.ComboLevel > 3:
+		return strconv.Itoa(m.ComboLevel)
+	}
+	return ""
+}
+
+// menuLines returns the menu's title and items with the focused item highlighted.
+func menuLines(m *game.Menu) []string {
+	lines := []string{style(1) + m.ID.Text() + resetStyle, ""}
+	for i, item := range m.Items {
+		focused := i == m.FocusedIndex
+
+		text := item.ID.Text()
+		if focused {
+			text = style(7) + " " + text + " " + resetStyle
+		} else {
+			text = " " + text + " "
+		}
+
+		var value string
+		switch {
+		case item.Selector != nil:
+			value = item.Selector.Text()
+		case item.Slider != nil:
+			value = strconv.Itoa(item.Slider.Value)
+		}
+		if value != "" {
+			if focused {
+				value = "< " + value + " >"
+			} else {
+				value = "  " + value
+			}
+			text += "  " + value
+		}
+
+		lines = append(lines, text)
+	}
+	return lines
+}
+
+// style returns the escape sequence that sets the graphic rendition parameters.
+func style(params ...int) string {
+	var strs []string
+	for _, p := range params {
+		strs = append(strs, strconv.Itoa(p))
+	}
+	return "\x1b[" + strings.Join(strs, ";") + "m"
+}
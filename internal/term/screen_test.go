@@ -0,0 +1,104 @@
+package term
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/btmura/blockcillin/internal/game"
+	"github.com/btmura/blockcillin/internal/locale"
+)
+
+// escapeRegexp matches the escape sequences that render writes.
+var escapeRegexp = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+func TestRender(t *testing.T) {
+	if err := locale.Init(locale.English); err != nil {
+		t.Fatalf("locale.Init: %v", err)
+	}
+
+	cell := func(state game.BlockState, color game.BlockColor, kind game.BlockKind) *game.Cell {
+		return &game.Cell{
+			Block:  &game.Block{State: state, Color: color, Kind: kind},
+			Marker: &game.Marker{},
+		}
+	}
+	ring := func(cells ...*game.Cell) *game.Ring {
+		return &game.Ring{Cells: cells}
+	}
+	static := func(kind game.BlockKind) *game.Cell {
+		return cell(game.BlockStatic, game.Red, kind)
+	}
+	cleared := func() *game.Cell {
+		return cell(game.BlockCleared, game.Red, game.BlockNormal)
+	}
+
+	marker := func(chainLevel int) *game.Cell {
+		c := cleared()
+		c.Marker = &game.Marker{State: game.MarkerShowing, ChainLevel: chainLevel}
+		return c
+	}
+
+	newGame := func(state game.GameState, b *game.Board) *game.Game {
+		g := game.New()
+		g.State = state
+		g.Board = b
+		g.HUD = &game.HUD{Speed: 3, TimeSec: 3725, Score: 120}
+		return g
+	}
+
+	for _, tt := range []struct {
+		desc string
+		game *game.Game
+		want []string
+	}{
+		{
+			desc: "main menu",
+			game: newGame(game.GameInitial, nil),
+			want: []string{
+				"b l o c k c i l l i n",
+				"",
+				" N E W  G A M E ",
+				" S T A T S ",
+				" O P T I O N S ",
+				" C R E D I T S ",
+				" E X I T ",
+			},
+		},
+		{
+			desc: "board around the selector with a marker and spare ring",
+			game: newGame(game.GamePlaying, &game.Board{
+				Rings: []*game.Ring{
+					ring(cleared(), cleared(), cleared(), cleared(), cleared(), cleared(), cleared(), cleared(), cleared(), cleared()),
+					ring(static(game.BlockBomb), static(game.BlockRainbow), static(game.BlockLocked), cell(game.BlockCracking, game.Red, game.BlockNormal),
+						cell(game.BlockExploding, game.Red, game.BlockNormal), marker(1), cleared(), cleared(), cleared(), static(game.BlockNormal)),
+				},
+				SpareRings: []*game.Ring{
+					ring(static(game.BlockNormal), static(game.BlockNormal), static(game.BlockNormal), static(game.BlockNormal), static(game.BlockNormal),
+						static(game.BlockNormal), static(game.BlockNormal), static(game.BlockNormal), static(game.BlockNormal), static(game.BlockNormal)),
+				},
+				RingCount: 2,
+				CellCount: 10,
+				Selector:  &game.Selector{X: 1, Y: 1},
+			}),
+			want: []string{
+				"S P E E D 3   T I M E 1:02:05   S C O R E 120",
+				"",
+				"                         ",
+				"       ()[<> ##]▓▓ ░░ x2 ",
+				" ▒▒ ▒▒ ▒▒ ▒▒ ▒▒ ▒▒ ▒▒ ▒▒ ",
+				"",
+				helpText,
+			},
+		},
+	} {
+		// Skip the cursor home and clearing sequences when comparing the lines.
+		var got []string
+		for _, l := range strings.Split(strings.TrimSuffix(render(tt.game), "\r\n"+clearBelow), "\r\n") {
+			got = append(got, escapeRegexp.ReplaceAllString(l, ""))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("[%s] render lines =\n%s\nwant\n%s", tt.desc, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package term
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/btmura/blockcillin/internal/game"
+)
+
+const (
+	// framesPerSec is how many frames are drawn per second.
+	// Terminals cannot keep up with the GL renderer's frame rate.
+	framesPerSec = 30
+
+	// raiseHoldUpdates is how many updates the raise key is held down after it was last seen.
+	// Terminals do not report key releases, so a held key is detected by its repeats,
+	// which start after a delay of about half a second.
+	raiseHoldUpdates = 0.6 / game.SecPerUpdate
+)
+
+// Run plays the game reading keys from in and drawing to out until
+// the game is done or Ctrl+C is pressed. The in file must be a terminal.
+func Run(g *game.Game, in *os.File, out io.Writer) error {
+	restore, err := makeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	if _, err := io.WriteString(out, hideCursor+clearScreen); err != nil {
+		return err
+	}
+	defer io.WriteString(out, resetStyle+clearScreen+cursorHome+showCursor)
+
+	input := make(chan []byte)
+	go readInput(in, input)
+
+	ticker := time.NewTicker(time.Second / framesPerSec)
+	defer ticker.Stop()
+
+	var raiseUpdates int
+	var lag float64
+	prevTime := time.Now()
+	for !g.Done() {
+		select {
+		case buf, ok := <-input:
+			if !ok {
+				input = nil
+				continue
+			}
+
+			keys, quit := decodeKeys(buf)
+			if quit {
+				return nil
+			}
+			for _, k := range keys {
+				action := game.KeyPress
+				if k == game.KeyRaise {
+					if raiseUpdates > 0 {
+						action = game.KeyRepeat
+					}
+					raiseUpdates = raiseHoldUpdates
+				}
+				g.KeyCallback(k, action)
+			}
+			continue
+
+		case <-ticker.C:
+		}
+
+		currTime := time.Now()
+		lag += currTime.Sub(prevTime).Seconds()
+		prevTime = currTime
+
+		for lag >= game.SecPerUpdate {
+			g.Update()
+			lag -= game.SecPerUpdate
+
+			if raiseUpdates > 0 {
+				if raiseUpdates--; raiseUpdates == 0 {
+					g.KeyCallback(game.KeyRaise, game.KeyRelease)
+				}
+			}
+		}
+
+		if _, err := io.WriteString(out, render(g)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readInput sends what is read from the reader to the channel until an error.
+func readInput(r io.Reader, input chan<- []byte) {
+	for {
+		buf := make([]byte, 64)
+		n, err := r.Read(buf)
+		if n > 0 {
+			input <- buf[:n]
+		}
+		if err != nil {
+			close(input)
+			return
+		}
+	}
+}
+
+// makeRaw puts the terminal in raw mode without echo so keys are read as they are pressed.
+// It returns a function to restore the terminal's previous settings.
+func makeRaw(f *os.File) (restore func(), err error) {
+	state, err := stty(f, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(f, "raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(f, strings.TrimSpace(state))
+	}, nil
+}
+
+// stty runs stty with the file as its terminal and returns its output.
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}